@@ -0,0 +1,183 @@
+/**
+ * The target platform that decides which files of a package are built
+ */
+export interface BuildContext {
+    goos: string;
+    goarch: string;
+}
+
+const KNOWN_OS = new Set([
+    'aix', 'android', 'darwin', 'dragonfly', 'freebsd', 'hurd', 'illumos', 'ios', 'js', 'linux',
+    'nacl', 'netbsd', 'openbsd', 'plan9', 'solaris', 'wasip1', 'windows', 'zos'
+]);
+
+const KNOWN_ARCH = new Set([
+    '386', 'amd64', 'amd64p32', 'arm', 'armbe', 'arm64', 'arm64be', 'loong64', 'mips', 'mipsle',
+    'mips64', 'mips64le', 'mips64p32', 'mips64p32le', 'ppc', 'ppc64', 'ppc64le', 'riscv', 'riscv64',
+    's390', 's390x', 'sparc', 'sparc64', 'wasm'
+]);
+
+const UNIX_OS = new Set([
+    'aix', 'android', 'darwin', 'dragonfly', 'freebsd', 'hurd', 'illumos', 'ios', 'linux',
+    'netbsd', 'openbsd', 'solaris'
+]);
+
+/**
+ * Returns the platform VSCode runs on, unless GOOS or GOARCH say otherwise
+ */
+export function defaultBuildContext(): BuildContext {
+    const platforms: Record<string, string> = { win32: 'windows', sunos: 'solaris' };
+    const archs: Record<string, string> = { x64: 'amd64', ia32: '386' };
+
+    return {
+        goos: process.env.GOOS || platforms[process.platform] || process.platform,
+        goarch: process.env.GOARCH || archs[process.arch] || process.arch
+    };
+}
+
+/**
+ * Reports whether a file is built for the context, judging by its name
+ * suffixes (_windows.go, _linux_arm64.go) and its build constraint lines
+ */
+export function matchesBuildContext(fileName: string, text: string, context: BuildContext): boolean {
+    if (!matchesFileName(fileName, context)) {
+        return false;
+    }
+
+    const constraint = parseBuildConstraint(text);
+    return constraint === undefined || evaluateBuildConstraint(constraint, context);
+}
+
+/**
+ * Applies the GOOS and GOARCH file name conventions of go/build
+ */
+function matchesFileName(fileName: string, context: BuildContext): boolean {
+    let name = fileName.replace(/\.go$/, '').replace(/_test$/, '');
+
+    // The first element is never a constraint, so linux.go is always built
+    const underscore = name.indexOf('_');
+    if (underscore === -1) {
+        return true;
+    }
+    name = name.substring(underscore);
+
+    const parts = name.split('_');
+    const last = parts[parts.length - 1];
+    const secondLast = parts[parts.length - 2];
+
+    if (parts.length >= 3 && KNOWN_OS.has(secondLast) && KNOWN_ARCH.has(last)) {
+        return matchesTag(secondLast, context) && matchesTag(last, context);
+    }
+    if (KNOWN_OS.has(last) || KNOWN_ARCH.has(last)) {
+        return matchesTag(last, context);
+    }
+    return true;
+}
+
+/**
+ * Returns the build constraint expression declared above the package clause.
+ * Legacy // +build lines are converted to the //go:build syntax.
+ */
+export function parseBuildConstraint(text: string): string | undefined {
+    const legacy: string[] = [];
+
+    for (const rawLine of text.split('\n')) {
+        const line = rawLine.trim();
+        if (!line) {
+            continue;
+        }
+        if (!line.startsWith('//')) {
+            break;
+        }
+
+        const goBuild = line.match(/^\/\/go:build\s+(.*)$/);
+        if (goBuild) {
+            return goBuild[1].trim();
+        }
+
+        // Space-separated options are ORed, comma-separated terms are ANDed
+        const plusBuild = line.match(/^\/\/\s*\+build\s+(.*)$/);
+        if (plusBuild) {
+            const options = plusBuild[1].trim().split(/\s+/).map(option => option.split(',').join(' && '));
+            legacy.push(options.length > 1 ? `(${options.join(' || ')})` : options[0]);
+        }
+    }
+
+    return legacy.length > 0 ? legacy.join(' && ') : undefined;
+}
+
+/**
+ * Evaluates a //go:build expression such as linux && (amd64 || arm64).
+ * Malformed expressions are treated as satisfied.
+ */
+export function evaluateBuildConstraint(expression: string, context: BuildContext): boolean {
+    const tokens = expression.match(/&&|\|\||[!()]|[\w.]+/g) ?? [];
+    let position = 0;
+
+    const parseOr = (): boolean => {
+        let value = parseAnd();
+        while (tokens[position] === '||') {
+            position++;
+            const right = parseAnd();
+            value = value || right;
+        }
+        return value;
+    };
+
+    const parseAnd = (): boolean => {
+        let value = parseUnary();
+        while (tokens[position] === '&&') {
+            position++;
+            const right = parseUnary();
+            value = value && right;
+        }
+        return value;
+    };
+
+    const parseUnary = (): boolean => {
+        const token = tokens[position++];
+        if (token === '!') {
+            return !parseUnary();
+        }
+        if (token === '(') {
+            const value = parseOr();
+            if (tokens[position++] !== ')') {
+                throw new Error('unbalanced parentheses');
+            }
+            return value;
+        }
+        if (token === undefined || !/^[\w.]+$/.test(token)) {
+            throw new Error(`unexpected token ${token}`);
+        }
+        return matchesTag(token, context);
+    };
+
+    try {
+        const value = parseOr();
+        return position === tokens.length ? value : true;
+    } catch {
+        return true;
+    }
+}
+
+/**
+ * Reports whether a single build tag is satisfied by the context
+ */
+function matchesTag(tag: string, context: BuildContext): boolean {
+    if (tag === context.goos || tag === context.goarch) {
+        return true;
+    }
+    if (tag === 'unix') {
+        return UNIX_OS.has(context.goos);
+    }
+
+    // Some platforms also satisfy the tag of the platform they derive from
+    if ((tag === 'linux' && context.goos === 'android')
+        || (tag === 'darwin' && context.goos === 'ios')
+        || (tag === 'solaris' && context.goos === 'illumos')) {
+        return true;
+    }
+
+    // Assume any go1.N release tag and the gc toolchain are available
+    return tag === 'gc' || /^go1\.\d+$/.test(tag);
+}
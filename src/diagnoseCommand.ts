@@ -0,0 +1,76 @@
+import * as vscode from 'vscode';
+import { GoAnalyzer } from './goAnalyzer';
+import { explainMissingLiteral } from './goParser';
+import { Logger } from './logger';
+import { GoStructSignatureProvider } from './structSignatureProvider';
+
+const DIAGNOSE_COMMAND = 'goStructSignature.diagnose';
+
+/**
+ * Registers the command that explains, step by step, what signature help
+ * finds at the cursor: the literal's type, where its struct was found and
+ * which field is active. The report goes to the output channel.
+ */
+export function registerDiagnoseCommand(
+    analyzer: GoAnalyzer,
+    provider: GoStructSignatureProvider,
+    logger: Logger
+): vscode.Disposable {
+    return vscode.commands.registerTextEditorCommand(DIAGNOSE_COMMAND, async editor => {
+        const document = editor.document;
+        const position = editor.selection.active;
+
+        logger.info(`Diagnosis for ${document.uri.fsPath}:${position.line + 1}:${position.character + 1}`);
+        const report = (line: string) => logger.info(`  ${line}`);
+        logger.show();
+
+        if (document.languageId !== 'go') {
+            report(`The document's language is ${document.languageId}, not go`);
+            return;
+        }
+        report(`The document declares ${analyzer.getDocumentStructs(document).length} struct(s)`);
+
+        const structContext = analyzer.findStructLiteralContext(document, position);
+        if (!structContext) {
+            report(`No struct literal: ${explainMissingLiteral(document.getText(), document.offsetAt(position))}`);
+            return;
+        }
+
+        const typeArgs = structContext.typeArgs ? `[${structContext.typeArgs.join(', ')}]` : '';
+        report(`Type name: ${structContext.typeName}${typeArgs}`);
+        report(structContext.keyed
+            ? `Keyed literal, keys set: ${structContext.usedKeys.join(', ') || 'none'}, key at cursor: ${structContext.activeKey ?? 'none'}`
+            : `Positional literal, element ${structContext.activeFieldIndex}`);
+
+        const tokenSource = new vscode.CancellationTokenSource();
+        try {
+            const structInfo = await analyzer.getLiteralStruct(document, structContext, tokenSource.token);
+            if (!structInfo) {
+                report(`Struct not found: ${structContext.typeName} is not declared in the document, its package or its imports, and gopls did not describe it`);
+                return;
+            }
+            report(`Struct ${structInfo.name} with ${structInfo.fields.length} field(s) found in ${analyzer.describeStructSource(document, structContext, structInfo)}`);
+
+            const help = await provider.provideSignatureHelp(document, position, tokenSource.token, {
+                triggerKind: vscode.SignatureHelpTriggerKind.Invoke,
+                isRetrigger: false,
+                activeSignatureHelp: undefined,
+                triggerCharacter: undefined
+            });
+            const signature = help?.signatures[help.activeSignature];
+            if (!help || !signature) {
+                report('No signature help was produced');
+                return;
+            }
+
+            const parameter = signature.parameters[help.activeParameter];
+            const label = parameter && Array.isArray(parameter.label)
+                ? signature.label.substring(parameter.label[0], parameter.label[1])
+                : undefined;
+            report(`Active parameter: ${help.activeParameter}${label ? ` (${label})` : ''}`);
+            report(`Signature: ${signature.label}`);
+        } finally {
+            tokenSource.dispose();
+        }
+    });
+}
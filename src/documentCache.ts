@@ -0,0 +1,45 @@
+import { ImportSpec, StructInfo, parseImports, parsePackageName, parseStructs } from './goParser';
+
+export interface ParsedDocument {
+    version: number;
+    packageName?: string;
+    imports: ImportSpec[];
+    structs: StructInfo[];
+}
+
+/**
+ * Keeps the parsed declarations of open documents so that lookups made while
+ * typing do not re-scan the whole document. Entries are keyed by document URI
+ * and replaced as soon as a newer version is requested.
+ */
+export class DocumentCache {
+    private documents = new Map<string, ParsedDocument>();
+
+    /**
+     * Returns the parsed declarations of a document version, parsing the text
+     * only when that version has not been seen yet
+     */
+    get(uri: string, version: number, getText: () => string): ParsedDocument {
+        const cached = this.documents.get(uri);
+        if (cached && cached.version === version) {
+            return cached;
+        }
+
+        const text = getText();
+        const parsed: ParsedDocument = {
+            version,
+            packageName: parsePackageName(text),
+            imports: parseImports(text),
+            structs: parseStructs(text)
+        };
+        this.documents.set(uri, parsed);
+        return parsed;
+    }
+
+    /**
+     * Drops the entry of a document, e.g. after it was closed
+     */
+    evict(uri: string): void {
+        this.documents.delete(uri);
+    }
+}
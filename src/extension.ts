@@ -1,19 +1,109 @@
 import * as vscode from 'vscode';
+import { registerDiagnoseCommand } from './diagnoseCommand';
+import { DocumentCache } from './documentCache';
+import { registerFillStructCommand } from './fillStructCommand';
+import { GoAnalyzer } from './goAnalyzer';
+import { WorkspaceResolvers } from './importResolver';
+import { Logger } from './logger';
+import { PackageScanner } from './packageScanner';
+import { GoStructCompletionProvider } from './structCompletionProvider';
+import { GoStructHoverProvider } from './structHoverProvider';
 import { GoStructSignatureProvider } from './structSignatureProvider';
 
+// Providers are registered by language id rather than file pattern, so Go
+// templates or other files that merely end in .go are left alone
+const GO_DOCUMENTS: vscode.DocumentSelector = { language: 'go', scheme: 'file' };
+
 export function activate(context: vscode.ExtensionContext) {
     console.log('Go Struct Signature Helper is now active');
 
+    // Keep the sibling file cache in sync with changes on disk. Deleting or
+    // renaming a directory only reports the directory, so deletions are
+    // watched for every path.
+    const scanner = new PackageScanner();
+    const watcher = vscode.workspace.createFileSystemWatcher('**/*.go', false, false, true);
+    watcher.onDidCreate(uri => scanner.invalidate(uri.fsPath));
+    watcher.onDidChange(uri => scanner.invalidate(uri.fsPath));
+    context.subscriptions.push(watcher);
+    const deleteWatcher = vscode.workspace.createFileSystemWatcher('**/*', true, true, false);
+    deleteWatcher.onDidDelete(uri => scanner.invalidate(uri.fsPath));
+    context.subscriptions.push(deleteWatcher);
+
+    // Each workspace root resolves imports with its own go.mod cache.
+    // go.mod and go.work changes can move where imported packages are found.
+    // GOROOT and GOMODCACHE are asked from the go tool in the background.
+    const resolver = new WorkspaceResolvers(
+        () => (vscode.workspace.workspaceFolders ?? []).map(folder => folder.uri.fsPath)
+    );
+    const goModWatcher = vscode.workspace.createFileSystemWatcher('**/go.{mod,work}');
+    goModWatcher.onDidCreate(() => resolver.invalidate());
+    goModWatcher.onDidChange(() => resolver.invalidate());
+    goModWatcher.onDidDelete(() => resolver.invalidate());
+    context.subscriptions.push(goModWatcher);
+    context.subscriptions.push(vscode.workspace.onDidChangeWorkspaceFolders(() => resolver.invalidate()));
+
+    // Re-parse edited documents shortly after typing pauses, so that
+    // signature help usually finds the current version already parsed
+    const documents = new DocumentCache();
+    const pendingParses = new Map<string, NodeJS.Timeout>();
+    context.subscriptions.push(vscode.workspace.onDidChangeTextDocument(event => {
+        const document = event.document;
+        if (document.languageId !== 'go' || event.contentChanges.length === 0) {
+            return;
+        }
+
+        const key = document.uri.toString();
+        clearTimeout(pendingParses.get(key));
+        pendingParses.set(key, setTimeout(() => {
+            pendingParses.delete(key);
+            documents.get(key, document.version, () => document.getText());
+        }, 100));
+    }));
+    context.subscriptions.push(vscode.workspace.onDidCloseTextDocument(document => {
+        const key = document.uri.toString();
+        clearTimeout(pendingParses.get(key));
+        pendingParses.delete(key);
+        documents.evict(key);
+    }));
+
     // Register the signature help provider for Go files
-    const provider = new GoStructSignatureProvider();
+    const logger = new Logger();
+    context.subscriptions.push(logger);
+    const analyzer = new GoAnalyzer(scanner, resolver, documents, logger);
+    const provider = new GoStructSignatureProvider(analyzer, logger);
 
     const disposable = vscode.languages.registerSignatureHelpProvider(
-        { language: 'go', scheme: 'file' },
+        GO_DOCUMENTS,
         provider,
-        '{', ',', '\n'  // Trigger on opening brace, comma, and newline
+        {
+            // Trigger on opening brace, comma, and newline
+            triggerCharacters: ['{', ',', '\n'],
+            // While the popup is open, re-evaluate when a key is typed or a
+            // nested literal closes so it follows the cursor or dismisses
+            retriggerCharacters: [':', '}']
+        }
     );
 
     context.subscriptions.push(disposable);
+
+    // Complete the field names that are not set yet in keyed literals
+    context.subscriptions.push(vscode.languages.registerCompletionItemProvider(
+        GO_DOCUMENTS,
+        new GoStructCompletionProvider(analyzer),
+        '{', ','
+    ));
+
+    // Insert the remaining fields of the literal at the cursor
+    context.subscriptions.push(registerFillStructCommand(analyzer));
+
+    // Explain what signature help finds at the cursor
+    context.subscriptions.push(registerDiagnoseCommand(analyzer, provider, logger));
+
+    // Show the full struct definition when hovering a struct type name
+    context.subscriptions.push(vscode.languages.registerHoverProvider(
+        GO_DOCUMENTS,
+        new GoStructHoverProvider(analyzer)
+    ));
 }
 
 export function deactivate() { }
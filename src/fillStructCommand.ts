@@ -0,0 +1,55 @@
+import * as vscode from 'vscode';
+import { GoAnalyzer } from './goAnalyzer';
+import { remainingFields } from './goParser';
+import { fieldSnippetLines } from './structFormatter';
+
+const FILL_STRUCT_COMMAND = 'goStructSignature.fillStruct';
+
+/**
+ * Registers the command that inserts the fields not set yet in the struct
+ * literal at the cursor, each initialized to its zero value
+ */
+export function registerFillStructCommand(analyzer: GoAnalyzer): vscode.Disposable {
+    return vscode.commands.registerTextEditorCommand(FILL_STRUCT_COMMAND, async editor => {
+        const document = editor.document;
+        const position = editor.selection.active;
+
+        const structContext = analyzer.findStructLiteralContext(document, position);
+        if (!structContext) {
+            vscode.window.showInformationMessage('Place the cursor inside a struct literal to fill its fields.');
+            return;
+        }
+        if (!structContext.expectingKey) {
+            vscode.window.showInformationMessage('Fields can only be filled at the start of a field in a keyed literal.');
+            return;
+        }
+
+        const tokenSource = new vscode.CancellationTokenSource();
+        const structInfo = await analyzer.getLiteralStruct(document, structContext, tokenSource.token);
+        tokenSource.dispose();
+
+        if (!structInfo) {
+            vscode.window.showInformationMessage(`Could not find the definition of ${structContext.typeName}.`);
+            return;
+        }
+
+        // Unexported fields of another package's struct cannot be set here
+        const fields = structInfo.fields.filter(analyzer.settableFieldFilter(document, structContext, structInfo));
+        const lines = fieldSnippetLines(
+            remainingFields(fields, structContext.usedKeys),
+            analyzer.structFieldTypeFilter(document, structContext, structInfo)
+        );
+        if (lines.length === 0) {
+            return;
+        }
+
+        // Right after the opening brace the fields go on their own lines;
+        // elsewhere they continue from the cursor
+        const linePrefix = document.lineAt(position.line).text.substring(0, position.character).trimEnd();
+        const snippet = linePrefix.endsWith('{')
+            ? `\n\t${lines.join('\n\t')}\n`
+            : lines.join('\n');
+
+        await editor.insertSnippet(new vscode.SnippetString(snippet), position);
+    });
+}
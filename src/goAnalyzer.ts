@@ -1,25 +1,49 @@
+import * as path from 'path';
 import * as vscode from 'vscode';
-
-export interface FieldInfo {
-    name: string;
-    type: string;
-    documentation?: string;
-    tag?: string;
-}
-
-export interface StructInfo {
-    name: string;
-    fields: FieldInfo[];
-    documentation?: string;
-}
+import { DocumentCache, ParsedDocument } from './documentCache';
+import {
+    FieldInfo,
+    PromotedField,
+    StructInfo,
+    collectPromotedFields,
+    findStructLiteralContext,
+    instantiateStruct,
+    isExported,
+    parseFields,
+    parseStructs,
+    splitTypeArgs
+} from './goParser';
+import { PackageResolver } from './importResolver';
+import { Logger } from './logger';
+import { PackageScanner } from './packageScanner';
+import { StructLocator } from './structLocator';
 
 export interface StructLiteralContext {
     typeName: string;
+    typeArgs?: string[];
     typePosition: vscode.Position;
     activeFieldIndex: number;
+    keyed: boolean;
+    usedKeys: string[];
+    activeKey?: string;
+    expectingKey: boolean;
+    inlineStruct?: StructInfo;
 }
 
+// Guards against alias chains that loop, e.g. type A B; type B A
+const MAX_UNDERLYING_DEPTH = 8;
+
 export class GoAnalyzer {
+    private locator: StructLocator;
+
+    constructor(
+        private scanner: PackageScanner,
+        resolver: PackageResolver,
+        private documents: DocumentCache,
+        private logger: Logger
+    ) {
+        this.locator = new StructLocator(scanner, resolver);
+    }
 
     /**
      * Finds if the cursor is inside a struct literal and returns context information
@@ -28,100 +52,70 @@ export class GoAnalyzer {
         document: vscode.TextDocument,
         position: vscode.Position
     ): StructLiteralContext | undefined {
-        const text = document.getText();
-        const offset = document.offsetAt(position);
-
-        // Find the opening brace that contains our position
-        let braceDepth = 0;
-        let openBraceOffset = -1;
-
-        for (let i = offset - 1; i >= 0; i--) {
-            const char = text[i];
-            if (char === '}') {
-                braceDepth++;
-            } else if (char === '{') {
-                if (braceDepth === 0) {
-                    openBraceOffset = i;
-                    break;
-                }
-                braceDepth--;
-            }
-        }
-
-        if (openBraceOffset === -1) {
+        // Skip the scan for documents that are not Go source
+        if (document.languageId !== 'go') {
             return undefined;
         }
 
-        // Look backwards from the opening brace to find the type name
-        const textBeforeBrace = text.substring(0, openBraceOffset).trimEnd();
-
-        // Match a type name (potentially with package prefix)
-        // Patterns: TypeName, pkg.TypeName, &TypeName, &pkg.TypeName
-        const typeMatch = textBeforeBrace.match(/(&?\s*)([A-Z_][a-zA-Z0-9_]*(?:\.[A-Z_][a-zA-Z0-9_]*)?)$/);
-
-        if (!typeMatch) {
+        const literal = findStructLiteralContext(document.getText(), document.offsetAt(position));
+        if (!literal) {
             return undefined;
         }
 
-        const typeName = typeMatch[2];
-        const typeStartOffset = textBeforeBrace.length - typeMatch[0].length + typeMatch[1].length;
-        const typePosition = document.positionAt(typeStartOffset);
-
-        // Count commas to determine active field index
-        const textInsideBraces = text.substring(openBraceOffset + 1, offset);
-        const activeFieldIndex = this.countActiveFieldIndex(textInsideBraces);
-
         return {
-            typeName,
-            typePosition,
-            activeFieldIndex
+            typeName: literal.typeName,
+            typeArgs: literal.typeArgs,
+            typePosition: document.positionAt(literal.typeStartOffset),
+            activeFieldIndex: literal.activeFieldIndex,
+            keyed: literal.keyed,
+            usedKeys: literal.usedKeys,
+            activeKey: literal.activeKey,
+            expectingKey: literal.expectingKey,
+            inlineStruct: literal.inlineStruct
         };
     }
 
     /**
-     * Counts the active field index based on commas, accounting for nested braces and strings
+     * Gets the struct a literal builds: the inline declaration of an
+     * anonymous struct, or the named struct instantiated with its type arguments
      */
-    private countActiveFieldIndex(text: string): number {
-        let index = 0;
-        let braceDepth = 0;
-        let parenDepth = 0;
-        let bracketDepth = 0;
-        let inDoubleQuote = false;
-        let inBacktick = false;
-        let prevChar = '';
-
-        for (const char of text) {
-            // Handle string literals - ignore everything inside them
-            if (char === '"' && !inBacktick && prevChar !== '\\') {
-                inDoubleQuote = !inDoubleQuote;
-            } else if (char === '`' && !inDoubleQuote) {
-                inBacktick = !inBacktick;
-            } else if (!inDoubleQuote && !inBacktick) {
-                // Only process structural characters when not in a string
-                if (char === '{') braceDepth++;
-                else if (char === '}') braceDepth--;
-                else if (char === '(') parenDepth++;
-                else if (char === ')') parenDepth--;
-                else if (char === '[') bracketDepth++;
-                else if (char === ']') bracketDepth--;
-                else if (char === ',' && braceDepth === 0 && parenDepth === 0 && bracketDepth === 0) {
-                    index++;
-                }
-            }
-            prevChar = char;
+    async getLiteralStruct(
+        document: vscode.TextDocument,
+        structContext: StructLiteralContext,
+        token: vscode.CancellationToken
+    ): Promise<StructInfo | undefined> {
+        if (structContext.inlineStruct) {
+            return structContext.inlineStruct;
         }
 
-        return index;
+        const declared = await this.getStructInfo(
+            document,
+            structContext.typeName,
+            structContext.typePosition,
+            token
+        );
+
+        // Show generic field types as instantiated, e.g. Value int for Box[int]
+        return declared && structContext.typeArgs
+            ? instantiateStruct(declared, structContext.typeArgs)
+            : declared;
     }
 
     /**
-     * Gets struct information using gopls via VSCode's hover provider
+     * Gets struct information from the structs declared in the document, its
+     * package or an imported package, falling back to gopls via VSCode's hover provider
      */
     async getStructInfo(
         document: vscode.TextDocument,
+        typeName: string,
         typePosition: vscode.Position,
         token: vscode.CancellationToken
     ): Promise<StructInfo | undefined> {
+        const found = this.findStruct(document, typeName);
+        if (found) {
+            return found;
+        }
+
         try {
             // Use VSCode's built-in hover command which triggers gopls
             const hovers = await vscode.commands.executeCommand<vscode.Hover[]>(
@@ -142,9 +136,150 @@ export class GoAnalyzer {
 
             return this.parseStructFromHover(hoverContent);
         } catch (error) {
-            console.error('Error getting struct info:', error);
+            this.logger.debug(() => `gopls hover failed for ${document.uri.fsPath}: ${error}`);
+            return undefined;
+        }
+    }
+
+    /**
+     * Resolves a type name to a struct without consulting gopls. The name is
+     * looked up from the document, or from the file declaring owner when given.
+     * Aliases and defined types are followed to the struct they name.
+     */
+    findStruct(document: vscode.TextDocument, typeName: string, owner?: StructInfo): StructInfo | undefined {
+        return this.followUnderlying(document, this.lookupType(document, typeName, owner), 0);
+    }
+
+    /**
+     * Returns a filter for the fields of the struct a literal builds that can
+     * be offered in the document. With hideUnexportedFromOtherPackages enabled,
+     * unexported fields of another package's struct are filtered out, since
+     * they cannot be set there.
+     */
+    settableFieldFilter(
+        document: vscode.TextDocument,
+        structContext: StructLiteralContext,
+        structInfo: StructInfo
+    ): (field: FieldInfo) => boolean {
+        const hideUnexported = vscode.workspace.getConfiguration('goStructSignature')
+            .get<boolean>('hideUnexportedFromOtherPackages', false)
+            && this.isFromOtherPackage(document, structContext, structInfo);
+        return hideUnexported ? field => isExported(field.name) : () => true;
+    }
+
+    /**
+     * Reports whether the struct a literal builds is declared by a package
+     * other than the document's. Structs described by gopls have no file, so
+     * a qualified type name decides for them.
+     */
+    private isFromOtherPackage(document: vscode.TextDocument, structContext: StructLiteralContext, structInfo: StructInfo): boolean {
+        if (structContext.inlineStruct) {
+            return false;
+        }
+        if (structInfo.file) {
+            return path.dirname(structInfo.file) !== path.dirname(document.uri.fsPath);
+        }
+        return structContext.typeName.includes('.');
+    }
+
+    /**
+     * Describes where the struct a literal builds was found, for diagnostics
+     */
+    describeStructSource(document: vscode.TextDocument, structContext: StructLiteralContext, structInfo: StructInfo): string {
+        if (structContext.inlineStruct) {
+            return 'the anonymous struct type of the literal';
+        }
+        if (structInfo.file) {
+            return `${structInfo.file}:${(structInfo.line ?? 0) + 1}`;
+        }
+        // Structs described by gopls come without a position
+        return structInfo.line !== undefined
+            ? `${document.uri.fsPath}:${structInfo.line + 1}`
+            : 'the gopls hover for the type';
+    }
+
+    /**
+     * Returns the structs declared in the document itself
+     */
+    getDocumentStructs(document: vscode.TextDocument): StructInfo[] {
+        return this.parseDocument(document).structs;
+    }
+
+    /**
+     * Returns a test for the field types of the struct a literal builds, such
+     * as Address, pkg.Config or Box[int], that name a known struct and so get
+     * a T{} zero value. Types are looked up from the file declaring the struct,
+     * without consulting gopls. Field types of another package's struct never
+     * pass, since the document would have to name them differently.
+     */
+    structFieldTypeFilter(
+        document: vscode.TextDocument,
+        structContext: StructLiteralContext,
+        structInfo: StructInfo
+    ): (type: string) => boolean {
+        if (this.isFromOtherPackage(document, structContext, structInfo)) {
+            return () => false;
+        }
+        return type => this.findStruct(document, splitTypeArgs(type).name, structInfo) !== undefined;
+    }
+
+    /**
+     * Returns the fields promoted into owner through its embedded fields
+     */
+    getPromotedFields(document: vscode.TextDocument, owner: StructInfo): PromotedField[] {
+        return collectPromotedFields(owner, (typeName, embedder) => this.findStruct(document, typeName, embedder));
+    }
+
+    /**
+     * Replaces an alias or defined type with the struct it names, keeping the
+     * declared name. Types that do not lead to a known struct are dropped.
+     */
+    private followUnderlying(document: vscode.TextDocument, found: StructInfo | undefined, depth: number): StructInfo | undefined {
+        if (!found?.underlying) {
+            return found;
+        }
+        if (depth >= MAX_UNDERLYING_DEPTH) {
+            return undefined;
+        }
+
+        const { name, typeArgs } = splitTypeArgs(found.underlying);
+        const target = this.followUnderlying(document, this.lookupType(document, name, found), depth + 1);
+        if (!target) {
             return undefined;
         }
+
+        const instantiated = typeArgs ? instantiateStruct(target, typeArgs) : target;
+        return {
+            ...instantiated,
+            name: found.name,
+            documentation: found.documentation ?? target.documentation
+        };
+    }
+
+    private lookupType(document: vscode.TextDocument, typeName: string, owner?: StructInfo): StructInfo | undefined {
+        if (owner?.file && owner.file !== document.uri.fsPath) {
+            return typeName.includes('.')
+                ? this.locator.findQualified(this.scanner.importsOf(owner.file), typeName, owner.file)
+                : this.locator.findUnqualifiedInFile(owner.file, typeName);
+        }
+
+        // Documents that are not on disk have no package or imports to search
+        const parsed = this.parseDocument(document);
+        if (document.uri.scheme !== 'file') {
+            return typeName.includes('.') ? undefined : parsed.structs.find(s => s.name === typeName);
+        }
+
+        const file = document.uri.fsPath;
+        return typeName.includes('.')
+            ? this.locator.findQualified(parsed.imports, typeName, file)
+            : this.locator.findUnqualified(file, parsed.packageName, parsed.structs, parsed.imports, typeName);
+    }
+
+    /**
+     * Returns the declarations of the document, parsed once per version
+     */
+    private parseDocument(document: vscode.TextDocument): ParsedDocument {
+        return this.documents.get(document.uri.toString(), document.version, () => document.getText());
     }
 
     /**
@@ -182,24 +317,19 @@ export class GoAnalyzer {
         const codeContent = codeBlockMatch ? codeBlockMatch[1] : content;
 
         // Match struct definition
-        const structMatch = codeContent.match(/type\s+(\w+)\s+struct\s*\{([\s\S]*?)\}/);
-        if (!structMatch) {
+        const struct = parseStructs(codeContent).find(s => !s.underlying);
+        if (!struct) {
             // Try simpler pattern for inline hover
             return this.parseInlineStruct(codeContent);
         }
 
-        const structName = structMatch[1];
-        const fieldsBlock = structMatch[2];
-
-        const fields = this.parseFields(fieldsBlock);
-
         // Extract documentation (text before the code block)
         const docMatch = content.match(/^([\s\S]*?)```/);
         const documentation = docMatch ? docMatch[1].trim() : undefined;
 
         return {
-            name: structName,
-            fields,
+            name: struct.name,
+            fields: struct.fields,
             documentation: documentation || undefined
         };
     }
@@ -214,39 +344,10 @@ export class GoAnalyzer {
             return undefined;
         }
 
-        const fields = this.parseFields(match[2]);
+        const fields = parseFields(match[2]);
         return {
             name: match[1],
             fields
         };
     }
-
-    /**
-     * Parses field definitions from struct body
-     */
-    private parseFields(fieldsBlock: string): FieldInfo[] {
-        const fields: FieldInfo[] = [];
-        const lines = fieldsBlock.split(/[;\n]/).map(l => l.trim()).filter(l => l);
-
-        for (const line of lines) {
-            // Skip empty lines and embedded types for now
-            if (!line || line.startsWith('//')) continue;
-
-            // Match: FieldName Type `tag` // comment
-            // or: FieldName Type // comment
-            // or: FieldName Type
-            const fieldMatch = line.match(/^(\w+)\s+([^`\/\n]+?)(?:\s+`([^`]*)`)?(?:\s*\/\/\s*(.*))?$/);
-
-            if (fieldMatch) {
-                fields.push({
-                    name: fieldMatch[1],
-                    type: fieldMatch[2].trim(),
-                    tag: fieldMatch[3],
-                    documentation: fieldMatch[4]
-                });
-            }
-        }
-
-        return fields;
-    }
 }
@@ -0,0 +1,1183 @@
+// Pure text-based helpers for reading Go source. Nothing in this module
+// depends on the vscode API so it can be unit tested directly.
+
+export interface FieldInfo {
+    name: string;
+    type: string;
+    documentation?: string;
+    tag?: string;
+    // Embedded fields are named after their type, e.g. Person for *Person
+    embedded?: boolean;
+    // Set when the documentation has a "Deprecated:" paragraph
+    deprecated?: boolean;
+}
+
+export interface StructInfo {
+    name: string;
+    fields: FieldInfo[];
+    documentation?: string;
+    // Type parameter names of a generic struct, e.g. ['K', 'V']
+    typeParams?: string[];
+    // File the struct was read from, when it is not the current document
+    file?: string;
+    // Zero-based line of the type name in the source it was parsed from
+    line?: number;
+    // Right-hand side of an alias or defined type, e.g. Config for
+    // type Settings Config. Such entries have no fields of their own.
+    underlying?: string;
+}
+
+export interface LiteralContext {
+    typeName: string;
+    // Type arguments of a generic instantiation such as Box[int]
+    typeArgs?: string[];
+    typeStartOffset: number;
+    activeFieldIndex: number;
+    // True when the literal uses Key: value elements
+    keyed: boolean;
+    // Keys of the elements before the one at the cursor
+    usedKeys: string[];
+    // Key of the element at the cursor, if already typed
+    activeKey?: string;
+    // True when the cursor is where a field key of a keyed literal could go
+    expectingKey: boolean;
+    // The struct declared inline by an anonymous struct{...}{ literal
+    inlineStruct?: StructInfo;
+}
+
+export interface PromotedField {
+    field: FieldInfo;
+    // Embedded fields leading to it from the outer struct, e.g. ['B', 'C']
+    path: string[];
+}
+
+export interface ImportSpec {
+    // Explicit import name: an alias, "." or "_"
+    name?: string;
+    path: string;
+}
+
+const IDENT = '[a-zA-Z_][a-zA-Z0-9_]*';
+// A bracketed list allowing one level of nesting, e.g. [4], [string] or [[]int]
+const BRACKETS = '\\[(?:[^\\[\\]]|\\[[^\\[\\]]*\\])*\\]';
+
+// The type written before a composite literal's brace. Patterns: TypeName,
+// pkg.TypeName, Box[int], []TypeName, [N]TypeName, map[K]TypeName
+const LITERAL_TYPE_PATTERN = new RegExp(
+    `(?<![\\w.])(?:(?:(?:map)?${BRACKETS}\\*?)+(?:${IDENT}\\.)?${IDENT}(?:${BRACKETS})?` +
+    `|(?:${IDENT}\\.)?[A-Z_][a-zA-Z0-9_]*(?:${BRACKETS})?)$`
+);
+
+// Array, slice and map prefixes of an anonymous struct type, e.g. []
+const ANONYMOUS_STRUCT_PREFIX = new RegExp(`(?:(?:map)?${BRACKETS}\\*?)*$`);
+const MAX_ANONYMOUS_STRUCT_LENGTH = 20000;
+
+// Composite literal types are looked for at most this far before their brace
+const MAX_LITERAL_TYPE_LENGTH = 1000;
+
+// Embedding chains are followed at most this deep when promoting fields
+const MAX_EMBEDDING_DEPTH = 10;
+
+interface LiteralType {
+    // Type expression of the literal, e.g. Person, []Person or map[string]Config
+    expression: string;
+    // Offset of the expression in the source text
+    offset: number;
+}
+
+export interface OpenDelimiter {
+    char: '{' | '(' | '[';
+    offset: number;
+}
+
+/**
+ * Scans text from start up to offset and returns the stack of delimiters
+ * that are still open at offset. Strings, runes and comments are skipped.
+ * Returns undefined when offset itself lies inside one of them.
+ */
+export function scanOpenDelimiters(text: string, offset: number, start = 0): OpenDelimiter[] | undefined {
+    const stack: OpenDelimiter[] = [];
+    let i = start;
+
+    while (i < offset) {
+        const skipped = skipStringOrComment(text, i, offset);
+        if (skipped !== i) {
+            if (skipped === offset && !closesBefore(text, i, offset)) {
+                return undefined;
+            }
+            i = skipped;
+            continue;
+        }
+
+        const char = text[i];
+        if (char === '{' || char === '(' || char === '[') {
+            stack.push({ char, offset: i });
+        } else if (char === '}' || char === ')' || char === ']') {
+            stack.pop();
+        }
+        i++;
+    }
+
+    return stack;
+}
+
+/**
+ * Returns the start of the line holding the top-level declaration that
+ * offset lies in. gofmt starts every top-level declaration in the first
+ * column, and delimiters never stay open from one declaration to the next,
+ * so scanning from there gives the same open delimiters as scanning the
+ * whole text before offset. A line that may be commented-out code or part
+ * of a raw string is no declaration, so the scan then starts at 0.
+ */
+function declarationStart(text: string, offset: number): number {
+    let lineStart = text.lastIndexOf('\n', offset - 1) + 1;
+    while (lineStart > 0 && !/^(?:func|type|var|const|import|package)\b/.test(text.substring(lineStart, lineStart + 8))) {
+        lineStart = lineStart >= 2 ? text.lastIndexOf('\n', lineStart - 2) + 1 : 0;
+    }
+    return lineStart > 0 && mayBeInsideCommentOrRawString(text, lineStart) ? 0 : lineStart;
+}
+
+/**
+ * Reports whether start may lie inside a block comment or raw string: a /*
+ * is not closed before it, or an odd number of backquotes precedes it.
+ * Delimiters inside other comments and strings can make this wrongly true,
+ * which only costs a scan from the start of the text.
+ */
+function mayBeInsideCommentOrRawString(text: string, start: number): boolean {
+    if (text.lastIndexOf('/*', start - 2) > text.lastIndexOf('*/', start - 2)) {
+        return true;
+    }
+
+    let backquotes = 0;
+    for (let i = text.indexOf('`'); i !== -1 && i < start; i = text.indexOf('`', i + 1)) {
+        backquotes++;
+    }
+    return backquotes % 2 === 1;
+}
+
+/**
+ * If a string, rune or comment starts at start, returns the offset just past
+ * it (clamped to end); otherwise returns start unchanged
+ */
+function skipStringOrComment(text: string, start: number, end: number): number {
+    const char = text[start];
+    const next = text[start + 1];
+
+    if (char === '/' && next === '/') {
+        const lineEnd = text.indexOf('\n', start);
+        return lineEnd === -1 ? end : Math.min(lineEnd, end);
+    }
+    if (char === '/' && next === '*') {
+        const commentEnd = text.indexOf('*/', start + 2);
+        return commentEnd === -1 ? end : Math.min(commentEnd + 2, end);
+    }
+    if (char === '"' || char === '\'') {
+        return Math.min(skipQuoted(text, start), end);
+    }
+    if (char === '`') {
+        const rawEnd = text.indexOf('`', start + 1);
+        return rawEnd === -1 ? end : Math.min(rawEnd + 1, end);
+    }
+    return start;
+}
+
+/**
+ * Reports whether the string, rune or comment starting at start is closed
+ * at or before offset. A line comment reaching offset never is.
+ */
+function closesBefore(text: string, start: number, offset: number): boolean {
+    if (text.startsWith('//', start)) {
+        return false;
+    }
+
+    const end = skipStringOrComment(text, start, text.length);
+    if (end > offset) {
+        return false;
+    }
+
+    if (text.startsWith('/*', start)) {
+        return end - start >= 4 && text.startsWith('*/', end - 2);
+    }
+
+    // The closing quote must not be escaped by an odd number of backslashes
+    const quote = text[start];
+    let backslashes = 0;
+    while (end - 2 - backslashes > start && text[end - 2 - backslashes] === '\\') {
+        backslashes++;
+    }
+    return end - start >= 2 && text[end - 1] === quote && (quote === '`' || backslashes % 2 === 0);
+}
+
+/**
+ * Returns the offset just past the interpreted string or rune literal
+ * starting at start. Unterminated literals end at the line break.
+ */
+function skipQuoted(text: string, start: number): number {
+    const quote = text[start];
+    let i = start + 1;
+    while (i < text.length) {
+        const char = text[i];
+        if (char === '\\') {
+            i += 2;
+            continue;
+        }
+        if (char === quote) {
+            return i + 1;
+        }
+        if (char === '\n') {
+            return i;
+        }
+        i++;
+    }
+    return i;
+}
+
+/**
+ * Finds the innermost composite literal that is open at offset and returns
+ * its type name along with the index of the field being edited
+ */
+export function findStructLiteralContext(text: string, offset: number): LiteralContext | undefined {
+    const stack = scanOpenDelimiters(text, offset, declarationStart(text, offset));
+    const innermost = stack?.[stack.length - 1];
+
+    // The cursor must be directly inside the braces, not in a nested call or
+    // index, and not in a comment or string
+    if (!stack || !innermost || innermost.char !== '{') {
+        return undefined;
+    }
+
+    // Only named types can be structs, not the slice, array or map literal itself
+    const literalType = resolveLiteralType(text, stack, stack.length - 1);
+    if (!literalType || !/^[a-zA-Z_]/.test(literalType.expression) || literalType.expression.startsWith('map[')) {
+        return undefined;
+    }
+
+    const openBraceOffset = innermost.offset;
+    const typeStartOffset = literalType.offset;
+
+    // Anonymous struct types declare their fields in the literal's type
+    const inlineBody = literalType.expression.match(/^struct\s*\{([\s\S]*)\}$/);
+    const inlineStruct: StructInfo | undefined = inlineBody
+        ? { name: 'struct', fields: parseFields(inlineBody[1]) }
+        : undefined;
+    const { name: typeName, typeArgs }: { name: string; typeArgs?: string[] } = inlineStruct
+        ? { name: inlineStruct.name }
+        : splitTypeArgs(literalType.expression);
+
+    // Count commas to determine active field index
+    const textInsideBraces = text.substring(openBraceOffset + 1, offset);
+    const activeFieldIndex = countActiveFieldIndex(textInsideBraces);
+
+    // The first element decides between keyed and positional syntax
+    const elements = splitElements(textInsideBraces);
+    const keys = elements.map(parseElementKey);
+    const activeKey = keys.pop();
+    const keyed = keys.length > 0 ? keys[0] !== undefined : activeKey !== undefined;
+
+    // A key can be typed at the start of an element, before any value
+    const atElementStart = /^\s*\w*$/.test(stripComments(elements[elements.length - 1]));
+
+    return {
+        typeName,
+        typeArgs,
+        typeStartOffset,
+        activeFieldIndex,
+        keyed,
+        usedKeys: keys.filter((key): key is string => key !== undefined),
+        activeKey,
+        expectingKey: atElementStart && (keyed || keys.length === 0),
+        inlineStruct
+    };
+}
+
+/**
+ * Explains why findStructLiteralContext finds no struct literal at offset,
+ * in words meant for diagnostics
+ */
+export function explainMissingLiteral(text: string, offset: number): string {
+    const stack = scanOpenDelimiters(text, offset, declarationStart(text, offset));
+    if (!stack) {
+        return 'the cursor is inside a comment, string or rune literal';
+    }
+
+    const innermost = stack[stack.length - 1];
+    if (!innermost) {
+        return 'the cursor is not inside any braces';
+    }
+    if (innermost.char !== '{') {
+        return `the cursor is inside ${innermost.char === '(' ? 'parentheses' : 'brackets'}, not directly inside braces`;
+    }
+
+    const literalType = resolveLiteralType(text, stack, stack.length - 1);
+    if (!literalType) {
+        return 'no type is written before the brace, so it is a block or function body rather than a composite literal';
+    }
+    return `the brace opens a ${literalType.expression} literal, whose elements are not struct fields`;
+}
+
+/**
+ * Determines the type of the composite literal opened by stack[index]. The
+ * type is either written before the brace or, for elided element literals,
+ * inherited from the enclosing slice, array or map literal.
+ */
+function resolveLiteralType(text: string, stack: OpenDelimiter[], index: number): LiteralType | undefined {
+    const brace = stack[index];
+    if (!brace || brace.char !== '{') {
+        return undefined;
+    }
+
+    // Look backwards from the opening brace to find the type name, within
+    // a bounded window so the cost does not grow with the document
+    const windowStart = Math.max(0, brace.offset - MAX_LITERAL_TYPE_LENGTH);
+    const textBeforeBrace = text.substring(windowStart, brace.offset).trimEnd();
+
+    // An element such as the second { in []Person{{...}, { or the value in map[string]Config{"k": {
+    const lastChar = textBeforeBrace[textBeforeBrace.length - 1];
+    if (lastChar === '{' || lastChar === ',' || lastChar === ':') {
+        const parentType = resolveLiteralType(text, stack, index - 1);
+        const element = parentType ? elementType(parentType, lastChar === ':') : undefined;
+        return element ? dereference(element) : undefined;
+    }
+
+    // An anonymous struct type, as in struct{ A int }{ or []struct{ ... }{
+    if (lastChar === '}') {
+        return anonymousStructType(text, text.substring(0, brace.offset).trimEnd());
+    }
+
+    // Match a type name (potentially with package prefix) and any array, slice or map prefix
+    const typeMatch = textBeforeBrace.match(LITERAL_TYPE_PATTERN);
+
+    if (!typeMatch) {
+        return undefined;
+    }
+
+    // A result type as in func NewConfig() *Config { or a range or condition
+    // naming a type opens a block, not a literal
+    const beforeType = textBeforeBrace.substring(0, textBeforeBrace.length - typeMatch[0].length).replace(/[\s*]+$/, '');
+    if (/(?:\)|\b(?:func|range|if|switch|for))$/.test(beforeType)) {
+        return undefined;
+    }
+
+    return {
+        expression: typeMatch[0],
+        offset: windowStart + textBeforeBrace.length - typeMatch[0].length
+    };
+}
+
+/**
+ * Matches textBeforeBrace, which ends with a closing brace, against an
+ * anonymous struct type and any array, slice or map prefix before it
+ */
+function anonymousStructType(text: string, textBeforeBrace: string): LiteralType | undefined {
+    const closingBrace = textBeforeBrace.length - 1;
+
+    // Only look a bounded distance back for the struct keyword
+    const structPattern = /\bstruct\s*\{/g;
+    structPattern.lastIndex = Math.max(0, closingBrace - MAX_ANONYMOUS_STRUCT_LENGTH);
+
+    let match: RegExpExecArray | null;
+    while ((match = structPattern.exec(textBeforeBrace)) !== null) {
+        const openBrace = match.index + match[0].length - 1;
+        if (findClosingBrace(text, openBrace) !== closingBrace) {
+            continue;
+        }
+
+        const prefix = textBeforeBrace.substring(Math.max(0, match.index - 200), match.index).match(ANONYMOUS_STRUCT_PREFIX);
+        const start = match.index - (prefix ? prefix[0].length : 0);
+        return { expression: textBeforeBrace.substring(start), offset: start };
+    }
+
+    return undefined;
+}
+
+/**
+ * Returns the type of an elided element literal inside a literal of the given
+ * array, slice or map type. Elements of a map are its keys unless afterColon.
+ */
+function elementType(literalType: LiteralType, afterColon: boolean): LiteralType | undefined {
+    const { expression, offset } = literalType;
+
+    if (expression.startsWith('map[')) {
+        const keyEnd = bracketEnd(expression, 'map'.length);
+        if (keyEnd === -1) {
+            return undefined;
+        }
+        return afterColon
+            ? { expression: expression.substring(keyEnd), offset: offset + keyEnd }
+            : { expression: expression.substring('map['.length, keyEnd - 1), offset: offset + 'map['.length };
+    }
+
+    // Array and slice elements may be indexed, as in [3]Point{2: {
+    if (expression.startsWith('[')) {
+        const lengthEnd = bracketEnd(expression, 0);
+        return lengthEnd === -1
+            ? undefined
+            : { expression: expression.substring(lengthEnd), offset: offset + lengthEnd };
+    }
+
+    return undefined;
+}
+
+/**
+ * Strips the pointer from an element type such as *Person, since an elided
+ * element literal {...} of a []*Person stands for &Person{...}
+ */
+function dereference(literalType: LiteralType): LiteralType {
+    const { expression, offset } = literalType;
+    return expression.startsWith('*')
+        ? { expression: expression.substring(1), offset: offset + 1 }
+        : literalType;
+}
+
+/**
+ * Returns the offset just past the bracket closing the one at start, or -1
+ */
+function bracketEnd(text: string, start: number): number {
+    let depth = 0;
+    for (let i = start; i < text.length; i++) {
+        if (text[i] === '[') {
+            depth++;
+        } else if (text[i] === ']' && --depth === 0) {
+            return i + 1;
+        }
+    }
+    return -1;
+}
+
+/**
+ * Splits a named type expression such as pkg.Box[int, string] into its name
+ * and type arguments
+ */
+export function splitTypeArgs(expression: string): { name: string; typeArgs?: string[] } {
+    const bracket = expression.indexOf('[');
+    if (bracket === -1 || !expression.endsWith(']')) {
+        return { name: expression };
+    }
+
+    const args = splitElements(expression.substring(bracket + 1, expression.length - 1))
+        .map(arg => arg.trim())
+        .filter(arg => arg);
+    return { name: expression.substring(0, bracket), typeArgs: args };
+}
+
+/**
+ * Returns the fields of a keyed literal that have not been set yet. Blank
+ * fields such as _ [4]byte padding can never be keys and are left out.
+ */
+export function remainingFields(fields: FieldInfo[], usedKeys: string[]): FieldInfo[] {
+    return fields.filter(field => field.name !== '_' && !usedKeys.includes(field.name));
+}
+
+/**
+ * Returns the value stored under key in a struct tag, following the
+ * key:"value" convention of reflect.StructTag
+ */
+export function lookupTag(tag: string, key: string): string | undefined {
+    const pairPattern = /\s*([^\s:"]+):"((?:[^"\\]|\\.)*)"/y;
+    let match: RegExpExecArray | null;
+    while ((match = pairPattern.exec(tag)) !== null) {
+        if (match[1] === key) {
+            return match[2].replace(/\\(.)/g, '$1');
+        }
+    }
+    return undefined;
+}
+
+/**
+ * Returns the JSON object key encoding/json uses for a field, or undefined
+ * when its tag does not name one: no json tag, json:",omitempty", or json:"-",
+ * which leaves the field out of the JSON entirely
+ */
+export function jsonKey(field: FieldInfo): string | undefined {
+    const value = field.tag !== undefined ? lookupTag(field.tag, 'json') : undefined;
+    if (value === undefined || value === '-') {
+        return undefined;
+    }
+    return value.split(',')[0] || undefined;
+}
+
+/**
+ * Reports whether a field or type name is exported, i.e. starts with an
+ * uppercase letter
+ */
+export function isExported(name: string): boolean {
+    return /^\p{Lu}/u.test(name);
+}
+
+/**
+ * Counts the active field index based on top-level commas. Commas inside
+ * nested delimiters, strings, runes and comments are not counted.
+ */
+export function countActiveFieldIndex(text: string): number {
+    return splitElements(text).length - 1;
+}
+
+/**
+ * Splits the text of a composite literal body into its top-level elements
+ */
+export function splitElements(text: string): string[] {
+    const elements: string[] = [];
+    let depth = 0;
+    let elementStart = 0;
+    let i = 0;
+
+    while (i < text.length) {
+        const skipped = skipStringOrComment(text, i, text.length);
+        if (skipped !== i) {
+            i = skipped;
+            continue;
+        }
+
+        const char = text[i];
+        if (char === '{' || char === '(' || char === '[') {
+            depth++;
+        } else if (char === '}' || char === ')' || char === ']') {
+            depth--;
+        } else if (char === ',' && depth === 0) {
+            elements.push(text.substring(elementStart, i));
+            elementStart = i + 1;
+        }
+        i++;
+    }
+
+    elements.push(text.substring(elementStart));
+    return elements;
+}
+
+/**
+ * Returns the field name of a Key: value element
+ */
+function parseElementKey(element: string): string | undefined {
+    const match = stripComments(element).match(/^\s*(\w+)\s*:/);
+    return match ? match[1] : undefined;
+}
+
+/**
+ * Removes line and block comments, leaving strings untouched
+ */
+function stripComments(text: string): string {
+    let result = '';
+    let i = 0;
+
+    while (i < text.length) {
+        const skipped = skipStringOrComment(text, i, text.length);
+        if (skipped !== i) {
+            if (text[i] !== '/') {
+                result += text.substring(i, skipped);
+            }
+            i = skipped;
+            continue;
+        }
+        result += text[i];
+        i++;
+    }
+
+    return result;
+}
+
+/**
+ * Parses every struct type declaration found in Go source text. Declarations
+ * are scanned independently, so syntax errors elsewhere in the file (or an
+ * unterminated struct body) do not prevent the other structs from parsing.
+ */
+export function parseStructs(text: string): StructInfo[] {
+    const structs: StructInfo[] = [];
+    const lineAt = lineIndex(text);
+    let i = 0;
+
+    while (i < text.length) {
+        const skipped = skipStringOrComment(text, i, text.length);
+        if (skipped !== i) {
+            i = skipped;
+            continue;
+        }
+
+        if (!isKeywordAt(text, i, 'type')) {
+            i++;
+            continue;
+        }
+
+        const specStart = skipWhitespace(text, i + 'type'.length);
+        i = text[specStart] === '('
+            ? parseTypeGroup(text, specStart, structs, lineAt)
+            : parseTypeSpec(text, specStart, structs, lineAt);
+    }
+
+    return structs;
+}
+
+/**
+ * Returns a function mapping offsets in text to zero-based line numbers
+ */
+function lineIndex(text: string): (offset: number) => number {
+    const lineStarts = [0];
+    for (let i = 0; i < text.length; i++) {
+        if (text[i] === '\n') {
+            lineStarts.push(i + 1);
+        }
+    }
+
+    return offset => {
+        let low = 0;
+        let high = lineStarts.length - 1;
+        while (low < high) {
+            const mid = Math.ceil((low + high) / 2);
+            if (lineStarts[mid] <= offset) {
+                low = mid;
+            } else {
+                high = mid - 1;
+            }
+        }
+        return low;
+    };
+}
+
+/**
+ * Parses the specs of a type ( ... ) group opened at start and returns the
+ * offset just past the group
+ */
+function parseTypeGroup(text: string, start: number, structs: StructInfo[], lineAt: (offset: number) => number): number {
+    let i = start + 1;
+
+    while (i < text.length) {
+        const skipped = skipStringOrComment(text, i, text.length);
+        if (skipped !== i) {
+            i = skipped;
+            continue;
+        }
+
+        const char = text[i];
+        if (char === ')') {
+            return i + 1;
+        }
+        if (/\s|;/.test(char)) {
+            i++;
+            continue;
+        }
+
+        const specEnd = parseTypeSpec(text, i, structs, lineAt);
+        // Skip the rest of a spec that is not a struct, e.g. Celsius float64
+        i = specEnd > i ? specEnd : findStatementEnd(text, i);
+    }
+
+    return i;
+}
+
+/**
+ * Parses a single "Name struct { ... }" spec at start, adding it to structs.
+ * Aliases and defined types naming another type are added without fields.
+ * Returns the offset just past the spec, or start if it is neither.
+ */
+function parseTypeSpec(text: string, start: number, structs: StructInfo[], lineAt: (offset: number) => number): number {
+    const namePattern = /(\w+)\s*/y;
+    namePattern.lastIndex = start;
+
+    const nameMatch = namePattern.exec(text);
+    if (!nameMatch) {
+        return start;
+    }
+
+    // Generic structs declare type parameters such as [K comparable, V any].
+    // A bracket without a constraint is an array length, as in [4]int.
+    let typeParams: string[] | undefined;
+    let structStart = start + nameMatch[0].length;
+    if (text[structStart] === '[') {
+        const paramsEnd = bracketEnd(text, structStart);
+        const paramsText = paramsEnd === -1 ? '' : text.substring(structStart + 1, paramsEnd - 1);
+        if (!/\w\s+\S/.test(paramsText)) {
+            return start;
+        }
+        typeParams = parseTypeParams(paramsText);
+        structStart = paramsEnd;
+    }
+
+    const structPattern = /\s*(?:=\s*)?struct\s*\{/y;
+    structPattern.lastIndex = structStart;
+
+    const structMatch = structPattern.exec(text);
+    if (!structMatch) {
+        return typeParams ? start : parseUnderlyingType(text, start, structStart, nameMatch[1], structs, lineAt);
+    }
+
+    const bodyStart = structStart + structMatch[0].length;
+    const closingBrace = findClosingBrace(text, bodyStart - 1);
+
+    // An unterminated body ends where the next top-level declaration begins
+    let bodyEnd = closingBrace;
+    if (bodyEnd === -1) {
+        const nextDecl = /\n(?:func|type|var|const|import)\b/g;
+        nextDecl.lastIndex = bodyStart;
+        bodyEnd = nextDecl.exec(text)?.index ?? text.length;
+    }
+
+    structs.push({
+        name: nameMatch[1],
+        fields: parseFields(text.substring(bodyStart, bodyEnd)),
+        documentation: parseLeadingComment(text, start),
+        typeParams,
+        line: lineAt(start)
+    });
+
+    return closingBrace === -1 ? bodyEnd : closingBrace + 1;
+}
+
+// Builtin types can never be the underlying type of a struct
+const PREDECLARED_TYPES = new Set([
+    'any', 'bool', 'byte', 'comparable', 'complex64', 'complex128', 'error',
+    'float32', 'float64', 'int', 'int8', 'int16', 'int32', 'int64', 'rune',
+    'string', 'uint', 'uint8', 'uint16', 'uint32', 'uint64', 'uintptr'
+]);
+
+/**
+ * Parses the type named by an alias (UserConfig = Config) or a defined
+ * type (Settings pkg.Config) whose name ends at typeStart. Builtin types and
+ * other right-hand sides such as []int or func() are not recorded.
+ */
+function parseUnderlyingType(
+    text: string,
+    start: number,
+    typeStart: number,
+    name: string,
+    structs: StructInfo[],
+    lineAt: (offset: number) => number
+): number {
+    const underlyingPattern = /\s*(?:=\s*)?((?:\w+\.)?\w+(?:\[[^\]\n]*\])?)[ \t]*(?=\/\/|[;)\n]|$)/y;
+    underlyingPattern.lastIndex = typeStart;
+
+    const underlyingMatch = underlyingPattern.exec(text);
+    if (!underlyingMatch || PREDECLARED_TYPES.has(underlyingMatch[1])) {
+        return start;
+    }
+
+    structs.push({
+        name,
+        fields: [],
+        documentation: parseLeadingComment(text, start),
+        line: lineAt(start),
+        underlying: underlyingMatch[1]
+    });
+
+    return typeStart + underlyingMatch[0].length;
+}
+
+/**
+ * Returns the named types a field type refers to, e.g. Address and
+ * time.Time for map[Address][]*time.Time. Builtin types are left out.
+ */
+export function referencedTypeNames(type: string): string[] {
+    const keywords = new Set(['chan', 'func', 'interface', 'map', 'struct']);
+    const names = type.match(/(?:[A-Za-z_]\w*\.)?[A-Za-z_]\w*/g) ?? [];
+    return [...new Set(names.filter(name => !keywords.has(name) && !PREDECLARED_TYPES.has(name)))];
+}
+
+/**
+ * Returns the names declared by a type parameter list such as K, V any
+ */
+function parseTypeParams(paramsText: string): string[] {
+    return splitElements(paramsText)
+        .map(param => param.trim().match(/^\w+/))
+        .filter((match): match is RegExpMatchArray => match !== null)
+        .map(match => match[0]);
+}
+
+/**
+ * Substitutes type arguments for the type parameters in a generic struct's
+ * field types. Missing arguments leave the parameter name in place.
+ */
+export function instantiateStruct(struct: StructInfo, typeArgs: string[]): StructInfo {
+    const params = struct.typeParams ?? [];
+    if (params.length === 0 || typeArgs.length === 0) {
+        return struct;
+    }
+
+    const substitute = (type: string) => type.replace(/(?<![\w.])\w+/g, ident => {
+        const index = params.indexOf(ident);
+        return index !== -1 && index < typeArgs.length ? typeArgs[index] : ident;
+    });
+
+    return {
+        ...struct,
+        name: `${struct.name}[${typeArgs.join(', ')}]`,
+        fields: struct.fields.map(field => ({ ...field, type: substitute(field.type) }))
+    };
+}
+
+/**
+ * Collects the fields promoted into struct through its embedded fields, at
+ * any depth. As in Go, a name resolves to the shallowest field declaring it:
+ * fields of struct itself shadow promoted ones, and a name declared twice at
+ * the same depth is ambiguous and not promoted at all. resolve looks up an
+ * embedded field's type as seen from the struct declaring it. Cycles end at a
+ * struct already on the embedding path; generic types that grow with every level, as in
+ * type L[T any] struct { *L[[]T] }, end at MAX_EMBEDDING_DEPTH.
+ */
+export function collectPromotedFields(
+    struct: StructInfo,
+    resolve: (typeName: string, owner: StructInfo) => StructInfo | undefined
+): PromotedField[] {
+    const promoted: PromotedField[] = [];
+    const taken = new Set(struct.fields.map(field => field.name));
+
+    // Embedding cycles, such as A embedding *B and B embedding *A, end at a
+    // struct already among the ancestors. A struct reached along two paths,
+    // as D in A{B; C} with B{D} and C{D}, is expanded on both so that its
+    // fields are ambiguous.
+    const embeddedOf = (owner: StructInfo, path: string[], ancestors: string[]) => owner.fields
+        .filter(field => field.embedded)
+        .map(field => {
+            const { name, typeArgs } = splitTypeArgs(field.type.replace(/^\*/, ''));
+            const resolved = resolve(name, owner);
+            return {
+                struct: resolved && typeArgs ? instantiateStruct(resolved, typeArgs) : resolved,
+                path: [...path, field.name]
+            };
+        })
+        .filter((entry): entry is { struct: StructInfo; path: string[] } =>
+            entry.struct !== undefined && !ancestors.includes(structKey(entry.struct)))
+        .map(entry => ({ ...entry, ancestors: [...ancestors, structKey(entry.struct)] }));
+
+    let level = embeddedOf(struct, [], [structKey(struct)]);
+    for (let depth = 0; level.length > 0 && depth < MAX_EMBEDDING_DEPTH; depth++) {
+        const candidates = new Map<string, PromotedField[]>();
+        for (const { struct: embedded, path } of level) {
+            for (const field of embedded.fields) {
+                candidates.set(field.name, [...(candidates.get(field.name) ?? []), { field, path }]);
+            }
+        }
+
+        for (const [name, found] of candidates) {
+            if (!taken.has(name) && found.length === 1) {
+                promoted.push(found[0]);
+            }
+            taken.add(name);
+        }
+
+        level = level.flatMap(entry => embeddedOf(entry.struct, entry.path, entry.ancestors));
+    }
+
+    return promoted;
+}
+
+function structKey(struct: StructInfo): string {
+    return `${struct.file ?? ''}:${struct.name}`;
+}
+
+/**
+ * Returns the offset of the brace closing the one at openOffset, or -1
+ */
+function findClosingBrace(text: string, openOffset: number): number {
+    let depth = 0;
+    let i = openOffset;
+
+    while (i < text.length) {
+        const skipped = skipStringOrComment(text, i, text.length);
+        if (skipped !== i) {
+            i = skipped;
+            continue;
+        }
+
+        if (text[i] === '{') {
+            depth++;
+        } else if (text[i] === '}' && --depth === 0) {
+            return i;
+        }
+        i++;
+    }
+
+    return -1;
+}
+
+/**
+ * Returns the offset of the newline or semicolon ending the statement that
+ * starts at start, ignoring those nested in delimiters
+ */
+function findStatementEnd(text: string, start: number): number {
+    let depth = 0;
+    let i = start;
+
+    while (i < text.length) {
+        const skipped = skipStringOrComment(text, i, text.length);
+        if (skipped !== i) {
+            i = skipped;
+            continue;
+        }
+
+        const char = text[i];
+        if (char === '{' || char === '(' || char === '[') {
+            depth++;
+        } else if (char === '}' || char === ')' || char === ']') {
+            if (depth === 0) {
+                return i;
+            }
+            depth--;
+        } else if ((char === '\n' || char === ';') && depth === 0) {
+            return i;
+        }
+        i++;
+    }
+
+    return i;
+}
+
+function isKeywordAt(text: string, offset: number, keyword: string): boolean {
+    return text.startsWith(keyword, offset)
+        && !/\w/.test(text[offset - 1] ?? '')
+        && !/\w/.test(text[offset + keyword.length] ?? '');
+}
+
+function skipWhitespace(text: string, offset: number): number {
+    while (offset < text.length && /\s/.test(text[offset])) {
+        offset++;
+    }
+    return offset;
+}
+
+/**
+ * Returns the name declared by the package clause, if any
+ */
+export function parsePackageName(text: string): string | undefined {
+    const match = text.match(/^\s*package\s+(\w+)/m);
+    return match ? match[1] : undefined;
+}
+
+/**
+ * Parses the import declarations of a Go source file
+ */
+export function parseImports(text: string): ImportSpec[] {
+    const imports: ImportSpec[] = [];
+    const importPattern = /^import\s*(?:\(([\s\S]*?)\)|([^\n]*))/gm;
+
+    let match: RegExpExecArray | null;
+    while ((match = importPattern.exec(text)) !== null) {
+        const specs = match[1] !== undefined ? match[1].split('\n') : [match[2]];
+        for (const spec of specs) {
+            const specMatch = spec.match(/^\s*([\w.]+)?\s*"([^"]+)"/);
+            if (specMatch) {
+                imports.push({ name: specMatch[1], path: specMatch[2] });
+            }
+        }
+    }
+
+    return imports;
+}
+
+/**
+ * Collects the contiguous // comment lines directly above offset
+ */
+function parseLeadingComment(text: string, offset: number): string | undefined {
+    const commentLines: string[] = [];
+
+    // Walk back line by line from the line the declaration starts on, so
+    // that only the comment is read rather than all of the text before it
+    let lineEnd = text.lastIndexOf('\n', offset - 1);
+    while (lineEnd !== -1) {
+        const lineStart = text.lastIndexOf('\n', lineEnd - 1) + 1;
+        const line = text.substring(lineStart, lineEnd).trim();
+        if (!line.startsWith('//')) {
+            break;
+        }
+        commentLines.unshift(line.replace(/^\/\/\s?/, ''));
+        lineEnd = lineStart - 1;
+    }
+
+    return commentLines.length > 0 ? commentLines.join('\n') : undefined;
+}
+
+/**
+ * Parses field definitions from struct body
+ */
+export function parseFields(fieldsBlock: string): FieldInfo[] {
+    const fields: FieldInfo[] = [];
+    const lines = splitFieldLines(fieldsBlock).map(l => l.trim());
+
+    // Comment lines directly above a field document it
+    let leadingComment: string[] = [];
+
+    for (const rawLine of lines) {
+        // A blank line detaches the comment above it from the next field
+        if (!rawLine) {
+            leadingComment = [];
+            continue;
+        }
+
+        if (!stripComments(rawLine).trim()) {
+            leadingComment.push(...commentLines(rawLine));
+            continue;
+        }
+
+        // Block comments inside the declaration, as in A int /* note */,
+        // document it unless a trailing // comment does
+        const { code: line, comments: inline } = extractBlockComments(rawLine);
+        const leading = inline.length > 0 ? inline.join('\n')
+            : leadingComment.length > 0 ? leadingComment.join('\n')
+            : undefined;
+        leadingComment = [];
+
+        // Match: FieldName Type `tag` // comment
+        // or: FieldName Type // comment
+        // or: FieldName Type
+        // or: Name1, Name2 Type, declaring several fields of that type
+        // Tags are taken whole, whatever they contain, and may also be
+        // written as interpreted strings: FieldName Type "json:\"name\""
+        const fieldMatch = line.match(/^(\w+(?:\s*,\s*\w+)*)\s+([^`"\/\n]+?)(?:\s+(?:`([^`]*)`|"((?:[^"\\]|\\.)*)"))?(?:\s*\/\/\s*(.*))?$/);
+
+        if (fieldMatch) {
+            const documentation = fieldMatch[5] ?? leading;
+            for (const name of fieldMatch[1].split(',')) {
+                fields.push({
+                    name: name.trim(),
+                    type: fieldMatch[2].trim(),
+                    tag: fieldMatch[3] ?? unquoteTag(fieldMatch[4]),
+                    documentation,
+                    deprecated: isDeprecated(documentation) || undefined
+                });
+            }
+            continue;
+        }
+
+        // Match embedded fields: Type, *Type, pkg.Type or *pkg.Type, any of
+        // them possibly generic as in Box[int]
+        const embeddedMatch = line.match(/^(\*?(?:\w+\.)?(\w+)(?:\[[^`"\/]*\])?)(?:\s+(?:`([^`]*)`|"((?:[^"\\]|\\.)*)"))?(?:\s*\/\/\s*(.*))?$/);
+
+        if (embeddedMatch) {
+            const documentation = embeddedMatch[5] ?? leading;
+            fields.push({
+                name: embeddedMatch[2],
+                type: embeddedMatch[1],
+                tag: embeddedMatch[3] ?? unquoteTag(embeddedMatch[4]),
+                documentation,
+                embedded: true,
+                deprecated: isDeprecated(documentation) || undefined
+            });
+        }
+    }
+
+    return fields;
+}
+
+/**
+ * Removes the block comments from a field declaration, returning the
+ * remaining code and the text of the comments
+ */
+function extractBlockComments(line: string): { code: string; comments: string[] } {
+    let code = '';
+    const comments: string[] = [];
+    let i = 0;
+
+    while (i < line.length) {
+        const skipped = skipStringOrComment(line, i, line.length);
+        if (skipped === i) {
+            code += line[i];
+            i++;
+            continue;
+        }
+
+        if (line.startsWith('/*', i)) {
+            comments.push(...commentLines(line.substring(i, skipped)));
+            code += ' ';
+        } else {
+            code += line.substring(i, skipped);
+        }
+        i = skipped;
+    }
+
+    return { code: code.trim(), comments };
+}
+
+/**
+ * Returns the value of a tag written as an interpreted string, without the
+ * escaping backslashes
+ */
+function unquoteTag(quoted: string | undefined): string | undefined {
+    return quoted?.replace(/\\(.)/g, '$1');
+}
+
+/**
+ * Follows the Go convention of starting a paragraph with "Deprecated:"
+ */
+function isDeprecated(documentation: string | undefined): boolean {
+    return documentation !== undefined && /^Deprecated:/m.test(documentation);
+}
+
+/**
+ * Joins a declaration spanning several lines into one, the way it would be
+ * written on a single line: func(\n\ta int,\n) becomes func(a int) and the
+ * fields of a nested struct type are separated by semicolons
+ */
+function joinLines(declaration: string): string {
+    const pieces = declaration.split('\n').map(piece => piece.trim()).filter(piece => piece);
+    let joined = pieces[0] ?? '';
+
+    for (const piece of pieces.slice(1)) {
+        const last = joined[joined.length - 1];
+        if (piece.startsWith(')') || piece.startsWith(']')) {
+            joined = joined.replace(/,$/, '') + piece;
+        } else if (piece.startsWith('}') || last === '{' || last === ',') {
+            joined += ' ' + piece;
+        } else if (last === '(' || last === '[') {
+            joined += piece;
+        } else {
+            joined += '; ' + piece;
+        }
+    }
+
+    return joined;
+}
+
+/**
+ * Returns the text lines of a `//` line comment or a `/* *\/` block comment,
+ * without the comment markers
+ */
+function commentLines(comment: string): string[] {
+    if (comment.startsWith('//')) {
+        return [comment.replace(/^\/\/\s?/, '').trim()];
+    }
+
+    return comment
+        .replace(/^\/\*/, '')
+        .replace(/\*\/$/, '')
+        .split('\n')
+        .map(line => line.trim().replace(/^\*\s?/, ''))
+        .filter(line => line);
+}
+
+/**
+ * Splits a struct body into field declarations on newlines and semicolons
+ * that are not nested in delimiters, strings or comments. Declarations that
+ * span lines, such as nested struct types, are joined onto one line; block
+ * comments standing on their own lines are kept intact.
+ */
+function splitFieldLines(fieldsBlock: string): string[] {
+    const lines: string[] = [];
+    let depth = 0;
+    let lineStart = 0;
+    let i = 0;
+
+    const pushLine = (end: number) => {
+        const line = fieldsBlock.substring(lineStart, end);
+        const isComment = /^\s*\/\*/.test(line) && !stripComments(line).trim();
+        lines.push(line.includes('\n') && !isComment ? joinLines(stripComments(line)) : line);
+        lineStart = end + 1;
+    };
+
+    while (i < fieldsBlock.length) {
+        const skipped = skipStringOrComment(fieldsBlock, i, fieldsBlock.length);
+        if (skipped !== i) {
+            i = skipped;
+            continue;
+        }
+
+        const char = fieldsBlock[i];
+        if (char === '{' || char === '(' || char === '[') {
+            depth++;
+        } else if (char === '}' || char === ')' || char === ']') {
+            depth--;
+        } else if ((char === '\n' || char === ';') && depth <= 0) {
+            pushLine(i);
+        }
+        i++;
+    }
+
+    pushLine(fieldsBlock.length);
+    return lines;
+}
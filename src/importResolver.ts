@@ -0,0 +1,408 @@
+import { execFile } from 'child_process';
+import * as os from 'os';
+import * as path from 'path';
+import { promisify } from 'util';
+import { SourceReader, fsSourceReader } from './packageScanner';
+
+export interface GoEnv {
+    goroot?: string;
+    gomodcache?: string;
+}
+
+export interface Replacement {
+    path: string;
+    version?: string;
+}
+
+export interface GoModule {
+    path: string;
+    requires: Map<string, string>;
+    replaces: Map<string, Replacement>;
+}
+
+export interface GoWork {
+    uses: string[];
+    replaces: Map<string, Replacement>;
+}
+
+interface ModuleRoot {
+    dir: string;
+    module: GoModule;
+    // True when vendor/modules.txt puts the module in vendor mode
+    vendored?: boolean;
+}
+
+interface WorkspaceRoot {
+    dir: string;
+    modules: ModuleRoot[];
+    replaces: Map<string, Replacement>;
+}
+
+/**
+ * Maps Go import paths of a file to the directories holding their source
+ */
+export interface PackageResolver {
+    resolvePackageDir(importPath: string, file: string): string | undefined;
+}
+
+const execFileAsync = promisify(execFile);
+
+/**
+ * Reads GOROOT and GOMODCACHE from the environment, asking the go tool for
+ * anything that is not set explicitly
+ */
+export async function detectGoEnv(): Promise<GoEnv> {
+    const env: GoEnv = {
+        goroot: process.env.GOROOT,
+        gomodcache: process.env.GOMODCACHE
+    };
+
+    if (!env.goroot || !env.gomodcache) {
+        try {
+            const { stdout } = await execFileAsync('go', ['env', 'GOROOT', 'GOMODCACHE'], { encoding: 'utf8', timeout: 5000 });
+            const [goroot, gomodcache] = stdout.split(/\r?\n/);
+            env.goroot = env.goroot || goroot || undefined;
+            env.gomodcache = env.gomodcache || gomodcache || undefined;
+        } catch {
+            // The go tool is not on the PATH; fall back to the default module cache
+        }
+    }
+
+    if (!env.gomodcache) {
+        const gopath = (process.env.GOPATH || path.join(os.homedir(), 'go')).split(path.delimiter)[0];
+        env.gomodcache = path.join(gopath, 'pkg', 'mod');
+    }
+
+    return env;
+}
+
+/**
+ * Starts detecting the Go environment without waiting for the go tool and
+ * returns a provider of the result, which is undefined until it is known
+ */
+export function detectGoEnvInBackground(): () => GoEnv | undefined {
+    let env: GoEnv | undefined;
+    detectGoEnv().then(detected => env = detected);
+    return () => env;
+}
+
+/**
+ * Splits a go.mod or go.work file into directives, each a list of tokens
+ * starting with its verb. Directives in a ( ... ) block get the block's verb.
+ */
+function parseDirectives(text: string): string[][] {
+    const directives: string[][] = [];
+    let block: string | undefined;
+
+    for (const rawLine of text.split('\n')) {
+        const line = rawLine.replace(/\/\/.*$/, '').trim();
+        if (!line) {
+            continue;
+        }
+
+        const blockStart = line.match(/^(\w+)\s*\($/);
+        if (blockStart) {
+            block = blockStart[1];
+            continue;
+        }
+        if (line === ')') {
+            block = undefined;
+            continue;
+        }
+
+        const tokens = line.split(/\s+/).map(t => t.replace(/^"(.*)"$/, '$1'));
+        if (block) {
+            tokens.unshift(block);
+        }
+        directives.push(tokens);
+    }
+
+    return directives;
+}
+
+function addReplacement(replaces: Map<string, Replacement>, tokens: string[]): void {
+    const arrow = tokens.indexOf('=>');
+    if (arrow > 1 && tokens[arrow + 1]) {
+        replaces.set(tokens[1], { path: tokens[arrow + 1], version: tokens[arrow + 2] });
+    }
+}
+
+/**
+ * Parses the module path, requirements and replacements from a go.mod file
+ */
+export function parseGoMod(text: string): GoModule | undefined {
+    const module: GoModule = { path: '', requires: new Map(), replaces: new Map() };
+
+    for (const tokens of parseDirectives(text)) {
+        switch (tokens[0]) {
+            case 'module':
+                module.path = tokens[1] ?? '';
+                break;
+            case 'require':
+                if (tokens.length >= 3) {
+                    module.requires.set(tokens[1], tokens[2]);
+                }
+                break;
+            case 'replace':
+                addReplacement(module.replaces, tokens);
+                break;
+        }
+    }
+
+    return module.path ? module : undefined;
+}
+
+/**
+ * Parses the module directories and replacements from a go.work file
+ */
+export function parseGoWork(text: string): GoWork {
+    const work: GoWork = { uses: [], replaces: new Map() };
+
+    for (const tokens of parseDirectives(text)) {
+        if (tokens[0] === 'use' && tokens[1]) {
+            work.uses.push(tokens[1]);
+        } else if (tokens[0] === 'replace') {
+            addReplacement(work.replaces, tokens);
+        }
+    }
+
+    return work;
+}
+
+/**
+ * Escapes a module path or version the way the module cache stores it on
+ * disk: every uppercase letter becomes '!' followed by its lowercase form
+ */
+export function escapeModulePath(modulePath: string): string {
+    return modulePath.replace(/[A-Z]/g, c => '!' + c.toLowerCase());
+}
+
+/**
+ * Maps Go import paths to the directories holding their source, using the
+ * importing file's go.mod and go.work, the module cache and GOROOT
+ */
+export class ImportResolver implements PackageResolver {
+    private env?: GoEnv;
+    private moduleRoots = new Map<string, ModuleRoot | undefined>();
+    private workspaceRoots = new Map<string, WorkspaceRoot | undefined>();
+
+    constructor(
+        private reader: SourceReader = fsSourceReader,
+        private envProvider: () => GoEnv | undefined = detectGoEnvInBackground()
+    ) { }
+
+    /**
+     * Returns the directory of the package imported as importPath from file
+     */
+    resolvePackageDir(importPath: string, file: string): string | undefined {
+        const root = this.findModuleRoot(path.dirname(file));
+
+        if (root) {
+            const { dir, module, vendored } = root;
+
+            // Packages of the importing module itself
+            const local = trimModulePrefix(importPath, module.path);
+            if (local !== undefined) {
+                return path.join(dir, local);
+            }
+
+            // Other modules of a go.work workspace the module belongs to
+            const workspace = this.findWorkspaceRoot(dir);
+            const workspaceModule = workspace && longestModuleRoot(importPath, workspace.modules);
+            if (workspaceModule) {
+                return path.join(workspaceModule.dir, trimModulePrefix(importPath, workspaceModule.module.path) ?? '');
+            }
+
+            // Dependencies, honoring replace directives. Those of go.work
+            // take precedence over the module's own.
+            const replaces = new Map([...module.replaces, ...(workspace?.replaces ?? [])]);
+            const dependency = longestModulePrefix(importPath, [...replaces.keys(), ...module.requires.keys()]);
+
+            // In vendor mode every dependency is copied under vendor/, even
+            // when it is replaced by a local directory
+            if (dependency && vendored && !workspace) {
+                return path.join(dir, 'vendor', importPath);
+            }
+
+            if (dependency) {
+                const rest = trimModulePrefix(importPath, dependency) ?? '';
+                const replacement = replaces.get(dependency);
+                const replacementDir = workspace?.replaces.has(dependency) ? workspace.dir : dir;
+
+                if (replacement && isLocalPath(replacement.path)) {
+                    return path.join(path.resolve(replacementDir, replacement.path), rest);
+                }
+
+                const modulePath = replacement ? replacement.path : dependency;
+                const version = replacement?.version ?? module.requires.get(dependency);
+                const gomodcache = this.getEnv().gomodcache;
+                if (version && gomodcache) {
+                    return path.join(gomodcache, `${escapeModulePath(modulePath)}@${escapeModulePath(version)}`, rest);
+                }
+            }
+        }
+
+        // Standard library packages have no dot in their first path element
+        const goroot = this.getEnv().goroot;
+        if (goroot && !importPath.split('/')[0].includes('.')) {
+            return path.join(goroot, 'src', importPath);
+        }
+
+        return undefined;
+    }
+
+    /**
+     * Drops cached go.mod and go.work data, e.g. after one of them changed
+     */
+    invalidate(): void {
+        this.moduleRoots.clear();
+        this.workspaceRoots.clear();
+    }
+
+    /**
+     * Returns the Go environment, which is empty while it is being detected:
+     * module cache and standard library packages are then not resolved
+     */
+    private getEnv(): GoEnv {
+        if (!this.env) {
+            this.env = this.envProvider();
+        }
+        return this.env ?? {};
+    }
+
+    private findModuleRoot(dir: string): ModuleRoot | undefined {
+        if (this.moduleRoots.has(dir)) {
+            return this.moduleRoots.get(dir);
+        }
+
+        let root: ModuleRoot | undefined;
+        const goMod = this.reader.readFile(path.join(dir, 'go.mod'));
+        const module = goMod !== undefined ? parseGoMod(goMod) : undefined;
+        if (module) {
+            root = { dir, module, vendored: this.reader.readFile(path.join(dir, 'vendor', 'modules.txt')) !== undefined };
+        } else {
+            const parent = path.dirname(dir);
+            root = parent !== dir ? this.findModuleRoot(parent) : undefined;
+        }
+
+        this.moduleRoots.set(dir, root);
+        return root;
+    }
+
+    private findWorkspaceRoot(dir: string): WorkspaceRoot | undefined {
+        if (this.workspaceRoots.has(dir)) {
+            return this.workspaceRoots.get(dir);
+        }
+
+        let root: WorkspaceRoot | undefined;
+        const goWork = this.reader.readFile(path.join(dir, 'go.work'));
+        if (goWork !== undefined) {
+            const work = parseGoWork(goWork);
+            const modules = work.uses
+                .map(use => path.resolve(dir, use))
+                .map(moduleDir => {
+                    const goMod = this.reader.readFile(path.join(moduleDir, 'go.mod'));
+                    const module = goMod !== undefined ? parseGoMod(goMod) : undefined;
+                    return module ? { dir: moduleDir, module } : undefined;
+                })
+                .filter((moduleRoot): moduleRoot is ModuleRoot => moduleRoot !== undefined);
+            root = { dir, modules, replaces: work.replaces };
+        } else {
+            const parent = path.dirname(dir);
+            root = parent !== dir ? this.findWorkspaceRoot(parent) : undefined;
+        }
+
+        this.workspaceRoots.set(dir, root);
+        return root;
+    }
+}
+
+/**
+ * Keeps a separate ImportResolver, and with it a separate go.mod cache, for
+ * each workspace root. Files outside every root share a fallback resolver.
+ */
+export class WorkspaceResolvers implements PackageResolver {
+    private resolvers = new Map<string, ImportResolver>();
+
+    constructor(
+        private roots: () => string[],
+        private createResolver: () => ImportResolver = sharingGoEnv()
+    ) { }
+
+    resolvePackageDir(importPath: string, file: string): string | undefined {
+        return this.resolverFor(file).resolvePackageDir(importPath, file);
+    }
+
+    /**
+     * Drops the caches of every root. A go.mod change in one root can affect
+     * the others through go.work and replace directives.
+     */
+    invalidate(): void {
+        this.resolvers.clear();
+    }
+
+    private resolverFor(file: string): ImportResolver {
+        const root = this.rootOf(file);
+        let resolver = this.resolvers.get(root);
+        if (!resolver) {
+            resolver = this.createResolver();
+            this.resolvers.set(root, resolver);
+        }
+        return resolver;
+    }
+
+    /**
+     * Returns the innermost root containing file, or '' outside every root
+     */
+    private rootOf(file: string): string {
+        let best = '';
+        for (const root of this.roots()) {
+            if (file.startsWith(root + path.sep) && root.length > best.length) {
+                best = root;
+            }
+        }
+        return best;
+    }
+}
+
+/**
+ * Returns a factory of resolvers sharing one Go environment, whose
+ * detection starts right away
+ */
+function sharingGoEnv(): () => ImportResolver {
+    const envProvider = detectGoEnvInBackground();
+    return () => new ImportResolver(fsSourceReader, envProvider);
+}
+
+/**
+ * Returns the package path relative to modulePath, or undefined if
+ * importPath is not inside that module
+ */
+function trimModulePrefix(importPath: string, modulePath: string): string | undefined {
+    if (importPath === modulePath) {
+        return '';
+    }
+    if (importPath.startsWith(modulePath + '/')) {
+        return importPath.substring(modulePath.length + 1);
+    }
+    return undefined;
+}
+
+function longestModuleRoot(importPath: string, roots: ModuleRoot[]): ModuleRoot | undefined {
+    const modulePath = longestModulePrefix(importPath, roots.map(root => root.module.path));
+    return roots.find(root => root.module.path === modulePath);
+}
+
+function longestModulePrefix(importPath: string, modulePaths: string[]): string | undefined {
+    let best: string | undefined;
+    for (const modulePath of modulePaths) {
+        if (trimModulePrefix(importPath, modulePath) !== undefined && (!best || modulePath.length > best.length)) {
+            best = modulePath;
+        }
+    }
+    return best;
+}
+
+function isLocalPath(replacementPath: string): boolean {
+    return replacementPath.startsWith('./') || replacementPath.startsWith('../') || path.isAbsolute(replacementPath);
+}
@@ -0,0 +1,38 @@
+import * as vscode from 'vscode';
+
+/**
+ * Writes to the extension's output channel. Debug lines only appear while
+ * goStructSignature.debug is enabled.
+ */
+export class Logger implements vscode.Disposable {
+    private channel = vscode.window.createOutputChannel('Go Struct Signature');
+
+    /**
+     * Writes a line regardless of the debug setting
+     */
+    info(message: string): void {
+        this.channel.appendLine(message);
+    }
+
+    /**
+     * Writes a line when debugging is enabled. Messages that are costly to
+     * build can be passed as a function, called only in that case.
+     */
+    debug(message: string | (() => string)): void {
+        if (vscode.workspace.getConfiguration('goStructSignature').get<boolean>('debug', false)) {
+            const text = typeof message === 'function' ? message() : message;
+            this.channel.appendLine(`[${new Date().toISOString()}] ${text}`);
+        }
+    }
+
+    /**
+     * Reveals the output channel without taking focus from the editor
+     */
+    show(): void {
+        this.channel.show(true);
+    }
+
+    dispose(): void {
+        this.channel.dispose();
+    }
+}
@@ -0,0 +1,165 @@
+import * as fs from 'fs';
+import * as path from 'path';
+import { BuildContext, defaultBuildContext, matchesBuildContext } from './buildConstraints';
+import { ImportSpec, StructInfo, parseImports, parsePackageName, parseStructs } from './goParser';
+
+/**
+ * Abstracts file system access so the scanner can be tested without disk I/O
+ */
+export interface SourceReader {
+    readDir(dir: string): string[];
+    readFile(file: string): string | undefined;
+}
+
+export const fsSourceReader: SourceReader = {
+    readDir(dir: string): string[] {
+        try {
+            return fs.readdirSync(dir);
+        } catch {
+            return [];
+        }
+    },
+    readFile(file: string): string | undefined {
+        try {
+            return fs.readFileSync(file, 'utf8');
+        } catch {
+            return undefined;
+        }
+    }
+};
+
+interface ParsedFile {
+    // False when build constraints exclude the file from the build context
+    included: boolean;
+    packageName?: string;
+    imports: ImportSpec[];
+    structs: StructInfo[];
+}
+
+/**
+ * Finds struct declarations in the other files of a Go package. Parsed files
+ * are cached until invalidated by a file change. Files excluded by build
+ * constraints for the build context are skipped.
+ */
+export class PackageScanner {
+    private files = new Map<string, ParsedFile>();
+    private dirs = new Map<string, string[]>();
+
+    constructor(
+        private reader: SourceReader = fsSourceReader,
+        private buildContext: BuildContext = defaultBuildContext()
+    ) { }
+
+    /**
+     * Looks up a struct declared in a sibling file of the given file that
+     * belongs to the same package
+     */
+    findStruct(file: string, packageName: string, typeName: string): StructInfo | undefined {
+        const includeTests = file.endsWith('_test.go');
+        const siblings = this.listGoFiles(path.dirname(file))
+            .filter(sibling => sibling !== file && (includeTests || !sibling.endsWith('_test.go')));
+
+        return this.findInFiles(siblings, packageName, typeName);
+    }
+
+    /**
+     * Looks up a struct declared by the package in dir, as seen by an importer
+     */
+    findStructInDir(dir: string, typeName: string): StructInfo | undefined {
+        const packageName = this.packageNameInDir(dir);
+        if (!packageName) {
+            return undefined;
+        }
+
+        return this.findInFiles(this.listPackageFiles(dir), packageName, typeName);
+    }
+
+    /**
+     * Returns the package name declared by the non-test files in dir
+     */
+    packageNameInDir(dir: string): string | undefined {
+        for (const file of this.listPackageFiles(dir)) {
+            const parsed = this.parseFile(file);
+            if (parsed.included && parsed.packageName) {
+                return parsed.packageName;
+            }
+        }
+        return undefined;
+    }
+
+    /**
+     * Returns the imports declared by a file
+     */
+    importsOf(file: string): ImportSpec[] {
+        return this.parseFile(file).imports;
+    }
+
+    /**
+     * Drops cached data for a file that was created, changed or deleted. A
+     * deleted or renamed directory drops every file below it.
+     */
+    invalidate(fileOrDir: string): void {
+        this.dirs.delete(path.dirname(fileOrDir));
+        if (this.files.delete(fileOrDir)) {
+            return;
+        }
+
+        const prefix = fileOrDir + path.sep;
+        for (const file of this.files.keys()) {
+            if (file.startsWith(prefix)) {
+                this.files.delete(file);
+            }
+        }
+        for (const dir of this.dirs.keys()) {
+            if (dir === fileOrDir || dir.startsWith(prefix)) {
+                this.dirs.delete(dir);
+            }
+        }
+    }
+
+    private findInFiles(files: string[], packageName: string, typeName: string): StructInfo | undefined {
+        for (const file of files) {
+            const parsed = this.parseFile(file);
+            if (!parsed.included || parsed.packageName !== packageName) {
+                continue;
+            }
+
+            const found = parsed.structs.find(s => s.name === typeName);
+            if (found) {
+                return found;
+            }
+        }
+
+        return undefined;
+    }
+
+    private listPackageFiles(dir: string): string[] {
+        return this.listGoFiles(dir).filter(file => !file.endsWith('_test.go'));
+    }
+
+    private listGoFiles(dir: string): string[] {
+        let files = this.dirs.get(dir);
+        if (!files) {
+            files = this.reader.readDir(dir)
+                .filter(name => name.endsWith('.go'))
+                .map(name => path.join(dir, name));
+            this.dirs.set(dir, files);
+        }
+        return files;
+    }
+
+    private parseFile(file: string): ParsedFile {
+        let parsed = this.files.get(file);
+        if (!parsed) {
+            const text = this.reader.readFile(file) ?? '';
+            parsed = {
+                included: matchesBuildContext(path.basename(file), text, this.buildContext),
+                packageName: parsePackageName(text),
+                imports: parseImports(text),
+                structs: parseStructs(text).map(s => ({ ...s, file }))
+            };
+            this.files.set(file, parsed);
+        }
+        return parsed;
+    }
+}
@@ -0,0 +1,49 @@
+import * as vscode from 'vscode';
+import { GoAnalyzer } from './goAnalyzer';
+import { remainingFields } from './goParser';
+
+export class GoStructCompletionProvider implements vscode.CompletionItemProvider {
+    private analyzer: GoAnalyzer;
+
+    constructor(analyzer: GoAnalyzer) {
+        this.analyzer = analyzer;
+    }
+
+    async provideCompletionItems(
+        document: vscode.TextDocument,
+        position: vscode.Position,
+        token: vscode.CancellationToken
+    ): Promise<vscode.CompletionItem[] | undefined> {
+
+        // Only offer keys where one can be typed in a keyed (or empty) literal
+        const structContext = this.analyzer.findStructLiteralContext(document, position);
+        if (!structContext || !structContext.expectingKey) {
+            return undefined;
+        }
+
+        const structInfo = await this.analyzer.getLiteralStruct(document, structContext, token);
+        if (!structInfo) {
+            return undefined;
+        }
+
+        // Unexported fields of another package's struct cannot be set here
+        const fields = structInfo.fields.filter(this.analyzer.settableFieldFilter(document, structContext, structInfo));
+
+        // Fields that are already set are not offered again
+        return remainingFields(fields, structContext.usedKeys).map((field, index) => {
+            const item = new vscode.CompletionItem(`${field.name}:`, vscode.CompletionItemKind.Field);
+            item.insertText = `${field.name}: `;
+            item.filterText = field.name;
+            item.detail = field.type;
+            // Keep declaration order rather than sorting alphabetically
+            item.sortText = String(index).padStart(4, '0');
+            if (field.deprecated) {
+                item.tags = [vscode.CompletionItemTag.Deprecated];
+            }
+            if (field.documentation) {
+                item.documentation = new vscode.MarkdownString(field.documentation);
+            }
+            return item;
+        });
+    }
+}
@@ -0,0 +1,109 @@
+import { FieldInfo, StructInfo } from './goParser';
+
+const NUMERIC_TYPE = /^(?:u?int(?:8|16|32|64)?|float(?:32|64)|complex(?:64|128)|byte|rune|uintptr)$/;
+
+/**
+ * Renders a struct as Go source with gofmt-style column alignment, using the
+ * first line of each field's documentation as its trailing comment
+ */
+export function formatStructDeclaration(struct: StructInfo): string {
+    if (struct.fields.length === 0) {
+        return `type ${struct.name} struct{}`;
+    }
+
+    const lines = formatFieldTable(struct.fields).map(line => `\t${line}`);
+    return `type ${struct.name} struct {\n${lines.join('\n')}\n}`;
+}
+
+/**
+ * Renders fields one per line as gofmt would align them: names, types, tags
+ * and trailing comments each in their own column
+ */
+export function formatFieldTable(fields: FieldInfo[]): string[] {
+    const rows = fields.map(field => [
+        field.embedded ? field.type : field.name,
+        field.embedded ? '' : field.type,
+        field.tag !== undefined ? `\`${field.tag}\`` : '',
+        trailingComment(field)
+    ]);
+
+    // Like gofmt, a cell only takes part in alignment when a later cell on
+    // its row has content. Columns empty on every row, such as the tags of a
+    // struct without any, take up no space.
+    const isAligned = (row: string[], column: number) => row.slice(column + 1).some(cell => cell);
+    const widths = [0, 1, 2].map(column =>
+        Math.max(0, ...rows.filter(row => isAligned(row, column)).map(row => row[column].length))
+    );
+
+    return rows.map(row => {
+        let line = '';
+        row.forEach((cell, column) => {
+            if (!isAligned(row, column)) {
+                line += cell;
+            } else if (widths[column] > 0) {
+                line += cell.padEnd(widths[column]) + ' ';
+            }
+        });
+        return line.trimEnd();
+    });
+}
+
+function trailingComment(field: FieldInfo): string {
+    const firstLine = field.documentation?.split('\n')[0];
+    return firstLine ? `// ${firstLine}` : '';
+}
+
+/**
+ * Picks the range of fields to show when at most maxFields fit, shifting the
+ * window just far enough that the active field stays visible
+ */
+export function visibleFieldRange(fieldCount: number, activeIndex: number, maxFields: number): { start: number; end: number } {
+    if (fieldCount <= maxFields) {
+        return { start: 0, end: fieldCount };
+    }
+
+    const active = Math.max(0, Math.min(activeIndex, fieldCount - 1));
+    const start = Math.max(0, active - maxFields + 1);
+    return { start, end: start + maxFields };
+}
+
+/**
+ * Returns the Go zero value written for a field of the given type, or
+ * undefined when it cannot be told from the type alone. Named types only get
+ * a T{} literal when isStruct confirms that they are structs; other named
+ * types such as time.Duration, enums and type parameters have no literal.
+ */
+export function zeroValue(type: string, isStruct: (type: string) => boolean): string | undefined {
+    if (type === 'string') {
+        return '""';
+    }
+    if (type === 'bool') {
+        return 'false';
+    }
+    if (NUMERIC_TYPE.test(type)) {
+        return '0';
+    }
+    if (/^(?:\*|\[\]|map\[|chan\b|<-\s*chan\b|func\b|interface\b)/.test(type) || type === 'any' || type === 'error') {
+        return 'nil';
+    }
+    // Arrays and anonymous structs have composite literals of any element type
+    if (/^(?:\[[^\]]|struct\b)/.test(type) || isStruct(type)) {
+        return `${type}{}`;
+    }
+    return undefined;
+}
+
+/**
+ * Builds one snippet line per field, each setting the field to its zero
+ * value in a numbered tab stop: Name: ${1:""}, Fields without a known zero
+ * value get an empty tab stop: Timeout: ${2},
+ */
+export function fieldSnippetLines(fields: FieldInfo[], isStruct: (type: string) => boolean): string[] {
+    const escape = (text: string) => text.replace(/[$}\\]/g, '\\$&');
+    return fields.map((field, index) => {
+        const zero = zeroValue(field.type, isStruct);
+        return zero !== undefined
+            ? `${field.name}: \${${index + 1}:${escape(zero)}},`
+            : `${field.name}: \${${index + 1}},`;
+    });
+}
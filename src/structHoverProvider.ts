@@ -0,0 +1,48 @@
+import * as vscode from 'vscode';
+import { GoAnalyzer } from './goAnalyzer';
+import { formatStructDeclaration } from './structFormatter';
+
+export class GoStructHoverProvider implements vscode.HoverProvider {
+    private analyzer: GoAnalyzer;
+
+    constructor(analyzer: GoAnalyzer) {
+        this.analyzer = analyzer;
+    }
+
+    provideHover(
+        document: vscode.TextDocument,
+        position: vscode.Position,
+        token: vscode.CancellationToken
+    ): vscode.Hover | undefined {
+        if (document.languageId !== 'go') {
+            return undefined;
+        }
+
+        // Hovering either part of pkg.Type resolves the qualified name
+        const range = document.getWordRangeAtPosition(position, /[a-zA-Z_][a-zA-Z0-9_]*(?:\.[a-zA-Z_][a-zA-Z0-9_]*)?/);
+        if (!range) {
+            return undefined;
+        }
+
+        // Only the parsed struct table is consulted; asking gopls for hovers
+        // here would call back into this provider
+        const structInfo = this.analyzer.findStruct(document, document.getText(range));
+        if (!structInfo) {
+            return undefined;
+        }
+
+        const markdown = new vscode.MarkdownString();
+        markdown.appendCodeblock(formatStructDeclaration(structInfo), 'go');
+        if (structInfo.documentation) {
+            markdown.appendMarkdown('\n\n' + structInfo.documentation);
+        }
+
+        // Code blocks cannot be styled, so deprecated fields are listed below
+        const deprecated = structInfo.fields.filter(field => field.deprecated);
+        if (deprecated.length > 0) {
+            markdown.appendMarkdown('\n\nDeprecated: ' + deprecated.map(field => `~~${field.name}~~`).join(', '));
+        }
+
+        return new vscode.Hover(markdown, range);
+    }
+}
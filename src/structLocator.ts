@@ -0,0 +1,88 @@
+import * as path from 'path';
+import { ImportSpec, StructInfo } from './goParser';
+import { PackageResolver } from './importResolver';
+import { PackageScanner } from './packageScanner';
+
+/**
+ * Looks up the structs a Go file refers to by name: those it declares, those
+ * of its package and those of the packages it imports
+ */
+export class StructLocator {
+    constructor(
+        private scanner: PackageScanner,
+        private resolver: PackageResolver
+    ) { }
+
+    /**
+     * Looks up an unqualified type name used in file. The file's own
+     * declarations come first, then the other files of its package, then the
+     * packages it imports with import . "path".
+     */
+    findUnqualified(
+        file: string,
+        packageName: string | undefined,
+        declared: StructInfo[],
+        imports: ImportSpec[],
+        typeName: string
+    ): StructInfo | undefined {
+        return declared.find(s => s.name === typeName)
+            ?? (packageName ? this.scanner.findStruct(file, packageName, typeName) : undefined)
+            ?? this.findDotImported(imports, typeName, file);
+    }
+
+    /**
+     * Looks up an unqualified type name used in file, which is read from disk
+     */
+    findUnqualifiedInFile(file: string, typeName: string): StructInfo | undefined {
+        return this.scanner.findStructInDir(path.dirname(file), typeName)
+            ?? this.findDotImported(this.scanner.importsOf(file), typeName, file);
+    }
+
+    /**
+     * Looks up an unqualified type name in the packages that file imports
+     * with import . "path". Files without dot-imports skip the scan.
+     */
+    findDotImported(imports: ImportSpec[], typeName: string, file: string): StructInfo | undefined {
+        for (const spec of imports) {
+            if (spec.name !== '.') {
+                continue;
+            }
+
+            const dir = this.resolver.resolvePackageDir(spec.path, file);
+            const found = dir ? this.scanner.findStructInDir(dir, typeName) : undefined;
+            if (found) {
+                return found;
+            }
+        }
+
+        return undefined;
+    }
+
+    /**
+     * Looks up a pkg.Type struct by locating the source of the package that
+     * file imports under that name
+     */
+    findQualified(imports: ImportSpec[], qualifiedName: string, file: string): StructInfo | undefined {
+        const [qualifier, typeName] = qualifiedName.split('.');
+
+        for (const spec of imports) {
+            if (spec.name === '_' || spec.name === '.' || (spec.name && spec.name !== qualifier)) {
+                continue;
+            }
+
+            const dir = this.resolver.resolvePackageDir(spec.path, file);
+            if (!dir) {
+                continue;
+            }
+
+            // Unaliased imports are referenced by the package's declared name
+            if (!spec.name && this.scanner.packageNameInDir(dir) !== qualifier) {
+                continue;
+            }
+
+            return this.scanner.findStructInDir(dir, typeName);
+        }
+
+        return undefined;
+    }
+}
@@ -1,11 +1,36 @@
 import * as vscode from 'vscode';
-import { GoAnalyzer, StructInfo, FieldInfo } from './goAnalyzer';
+import { GoAnalyzer } from './goAnalyzer';
+import {
+    StructInfo,
+    FieldInfo,
+    PromotedField,
+    explainMissingLiteral,
+    jsonKey,
+    referencedTypeNames,
+    remainingFields
+} from './goParser';
+import { Logger } from './logger';
+import { formatFieldTable, visibleFieldRange, zeroValue } from './structFormatter';
+
+interface RenderOptions {
+    showTypes: boolean;
+    showDocumentation: boolean;
+    showTags: boolean;
+    maxFields: number;
+    showZeroValues: boolean;
+    // 'json' leads with the field's JSON key when its tag names one
+    fieldLabel: 'name' | 'json';
+    // Tells the named types with a T{} zero value apart from other ones
+    isStruct: (type: string) => boolean;
+}
 
 export class GoStructSignatureProvider implements vscode.SignatureHelpProvider {
     private analyzer: GoAnalyzer;
+    private logger: Logger;
 
-    constructor() {
-        this.analyzer = new GoAnalyzer();
+    constructor(analyzer: GoAnalyzer, logger: Logger) {
+        this.analyzer = analyzer;
+        this.logger = logger;
     }
 
     async provideSignatureHelp(
@@ -17,56 +42,208 @@ export class GoStructSignatureProvider implements vscode.SignatureHelpProvider {
 
         // Check if we're inside a struct literal
         const structContext = this.analyzer.findStructLiteralContext(document, position);
+        const where = `${document.uri.fsPath}:${position.line + 1}:${position.character + 1}`;
         if (!structContext) {
+            this.logger.debug(() => `${where}: no struct literal, ${explainMissingLiteral(document.getText(), document.offsetAt(position))}`);
             return undefined;
         }
+        this.logger.debug(`${where}: ${structContext.keyed ? 'keyed' : 'positional'} literal of ${structContext.typeName}`);
 
-        // Get struct information from gopls via hover
-        const structInfo = await this.analyzer.getStructInfo(
-            document,
-            structContext.typePosition,
-            token
-        );
-
-        if (!structInfo) {
+        // Look up the innermost literal's struct type
+        const declared = await this.analyzer.getLiteralStruct(document, structContext, token);
+        if (!declared) {
+            this.logger.debug(`${where}: ${structContext.typeName} was not found`);
             return undefined;
         }
+        this.logger.debug(() => `${where}: found ${declared.name} in ${this.analyzer.describeStructSource(document, structContext, declared)}`);
+
+        const config = vscode.workspace.getConfiguration('goStructSignature');
+
+        // Unexported fields cannot be set from another package, neither by key
+        // nor by position
+        const isSettable = this.analyzer.settableFieldFilter(document, structContext, declared);
+        const structInfo = { ...declared, fields: declared.fields.filter(isSettable) };
+
+        const options: RenderOptions = {
+            showTypes: config.get<boolean>('showTypes', true),
+            showDocumentation: config.get<boolean>('showDocumentation', true),
+            showTags: config.get<boolean>('showTags', true),
+            maxFields: Math.max(1, config.get<number>('maxFields', 25)),
+            showZeroValues: config.get<boolean>('showZeroValues', false),
+            fieldLabel: config.get<string>('fieldLabel', 'name') === 'json' ? 'json' : 'name',
+            isStruct: this.analyzer.structFieldTypeFilter(document, structContext, declared)
+        };
+
+        // Embedded fields list the fields they promote, at any depth, in
+        // their documentation. Hidden fields still shadow deeper ones.
+        const promoted = new Map<string, PromotedField[]>();
+        for (const promotedField of this.analyzer.getPromotedFields(document, declared)) {
+            if (!isSettable(promotedField.field)) {
+                continue;
+            }
+            const embeddedName = promotedField.path[0];
+            promoted.set(embeddedName, [...(promoted.get(embeddedName) ?? []), promotedField]);
+        }
 
-        // Build signature help
-        return this.buildSignatureHelp(structInfo, structContext.activeFieldIndex);
+        // Field types naming a known struct link to its declaration
+        const typeLinks = new Map<string, string[]>();
+        for (const field of structInfo.fields) {
+            typeLinks.set(field.name, referencedTypeNames(field.type).flatMap(name => {
+                const target = this.analyzer.findStruct(document, name, structInfo);
+                return target ? [`[\`${name}\`](${this.declarationUri(document, target)})`] : [];
+            }));
+        }
+
+        // Keyed literals only list the fields that have not been set yet
+        let help: vscode.SignatureHelp;
+        if (structContext.keyed) {
+            const remaining = remainingFields(structInfo.fields, structContext.usedKeys);
+            const activeIndex = remaining.findIndex(f => f.name === structContext.activeKey);
+            help = this.buildSignatureHelp({ ...structInfo, fields: remaining }, Math.max(activeIndex, 0), promoted, typeLinks, options);
+        } else {
+            help = this.buildSignatureHelp(structInfo, structContext.activeFieldIndex, promoted, typeLinks, options);
+        }
+
+        this.logger.debug(`${where}: active parameter ${help.activeParameter}`);
+        return help;
     }
 
-    private buildSignatureHelp(structInfo: StructInfo, activeFieldIndex: number): vscode.SignatureHelp {
+    private buildSignatureHelp(
+        structInfo: StructInfo,
+        activeFieldIndex: number,
+        promoted: Map<string, PromotedField[]>,
+        typeLinks: Map<string, string[]>,
+        options: RenderOptions
+    ): vscode.SignatureHelp {
         const signatureHelp = new vscode.SignatureHelp();
 
-        // Build the signature label showing all fields
-        const fieldLabels = structInfo.fields.map((f: FieldInfo) => `${f.name} ${f.type}`);
-        const signatureLabel = `${structInfo.name}{${fieldLabels.join(', ')}}`;
+        // Long structs only show a window of fields around the active one
+        const { start, end } = visibleFieldRange(structInfo.fields.length, activeFieldIndex, options.maxFields);
+        const visibleFields = structInfo.fields.slice(start, end);
+        const hiddenAfter = structInfo.fields.length - end;
+
+        // Build the signature label showing the visible fields
+        const labelPrefix = start > 0 ? `${structInfo.name}{…, ` : `${structInfo.name}{`;
+        const fieldLabels = visibleFields.map((f: FieldInfo) => this.fieldLabel(f, options));
+        const overflow = hiddenAfter > 0 ? [`…and ${hiddenAfter} more`] : [];
+        const signatureLabel = `${labelPrefix}${[...fieldLabels, ...overflow].join(', ')}}`;
 
         const signature = new vscode.SignatureInformation(signatureLabel);
 
-        // Add documentation if available
-        if (structInfo.documentation) {
-            signature.documentation = new vscode.MarkdownString(structInfo.documentation);
+        // Describe the struct above the fields, falling back to its name
+        const documentation = new vscode.MarkdownString();
+        if (options.showDocumentation) {
+            documentation.appendMarkdown((structInfo.documentation ?? `\`${structInfo.name}\``) + '\n\n');
         }
 
-        // Add parameter information for each field
-        let currentOffset = structInfo.name.length + 1; // After "StructName{"
+        // The visible fields follow as an aligned table, reading like the
+        // gofmt-formatted source
+        const tableFields = visibleFields.map(field => ({
+            ...field,
+            type: options.showTypes || field.embedded ? field.type : '',
+            tag: options.showTags ? field.tag : undefined,
+            documentation: options.showDocumentation ? field.documentation : undefined
+        }));
+        if (tableFields.length > 0) {
+            documentation.appendCodeblock(formatFieldTable(tableFields).join('\n'), 'go');
+        }
+        signature.documentation = documentation;
 
-        for (const field of structInfo.fields) {
-            const fieldLabel = `${field.name} ${field.type}`;
+        // Add parameter information for each visible field
+        let currentOffset = labelPrefix.length;
+
+        for (const field of visibleFields) {
+            const fieldLabel = this.fieldLabel(field, options);
             const param = new vscode.ParameterInformation(
                 [currentOffset, currentOffset + fieldLabel.length],
-                field.documentation ? new vscode.MarkdownString(field.documentation) : undefined
+                this.fieldDocumentation(field, promoted.get(field.name), typeLinks.get(field.name), options)
             );
             signature.parameters.push(param);
             currentOffset += fieldLabel.length + 2; // +2 for ", "
         }
 
+        // Highlight the field at the cursor, clamped to the last field
+        const activeParameter = Math.max(0, Math.min(activeFieldIndex, structInfo.fields.length - 1) - start);
+        signature.activeParameter = activeParameter;
+
         signatureHelp.signatures = [signature];
         signatureHelp.activeSignature = 0;
-        signatureHelp.activeParameter = Math.min(activeFieldIndex, structInfo.fields.length - 1);
+        signatureHelp.activeParameter = activeParameter;
 
         return signatureHelp;
     }
+
+    /**
+     * Renders a single field as it appears in the signature label
+     */
+    private fieldLabel(field: FieldInfo, options: RenderOptions): string {
+        // Embedded fields are written as just their type, as in the declaration
+        let label = field.embedded ? field.type : field.name;
+
+        // In JSON mode the key comes first, the Go name after it
+        const key = options.fieldLabel === 'json' ? jsonKey(field) : undefined;
+        if (key !== undefined) {
+            label = `${key} (${label})`;
+        }
+
+        if (options.showTypes && !field.embedded) {
+            label += ` ${field.type}`;
+        }
+        // Named types that are not known structs show no zero value, rather
+        // than a guess such as time.Duration{}
+        const zero = options.showZeroValues ? zeroValue(field.type, options.isStruct) : undefined;
+        if (zero !== undefined) {
+            label += ` = ${zero}`;
+        }
+        if (options.showTags && field.tag) {
+            label += ` \`${field.tag}\``;
+        }
+        // Labels are plain text, so strikethrough is left to the documentation
+        if (field.deprecated) {
+            label += ' (deprecated)';
+        }
+        return label;
+    }
+
+    /**
+     * Builds the documentation shown for the active field
+     */
+    private fieldDocumentation(
+        field: FieldInfo,
+        promoted: PromotedField[] | undefined,
+        typeLinks: string[] | undefined,
+        options: RenderOptions
+    ): vscode.MarkdownString | undefined {
+        const parts: string[] = [];
+        if (field.deprecated) {
+            parts.push(`~~${field.name}~~ is deprecated`);
+        }
+        if (options.showDocumentation && field.documentation) {
+            parts.push(field.documentation);
+        }
+        if (promoted && promoted.length > 0) {
+            parts.push(`Promotes: ${promoted.map(p => this.promotedLabel(p)).join(', ')}`);
+        }
+        if (typeLinks && typeLinks.length > 0) {
+            parts.push(`Go to definition: ${typeLinks.join(', ')}`);
+        }
+        return parts.length > 0 ? new vscode.MarkdownString(parts.join('\n\n')) : undefined;
+    }
+
+    /**
+     * Renders a promoted field, noting the embedded fields it comes through
+     * beyond the first, e.g. `City` (via Address)
+     */
+    private promotedLabel(promoted: PromotedField): string {
+        const via = promoted.path.slice(1);
+        return via.length > 0 ? `\`${promoted.field.name}\` (via ${via.join('.')})` : `\`${promoted.field.name}\``;
+    }
+
+    /**
+     * Returns a link target opening the file that declares target at its line
+     */
+    private declarationUri(document: vscode.TextDocument, target: StructInfo): string {
+        const uri = target.file ? vscode.Uri.file(target.file) : document.uri;
+        return uri.with({ fragment: `L${(target.line ?? 0) + 1}` }).toString();
+    }
 }
@@ -0,0 +1,58 @@
+import * as assert from 'assert';
+import { BuildContext, evaluateBuildConstraint, matchesBuildContext, parseBuildConstraint } from '../buildConstraints';
+
+describe('buildConstraints', () => {
+    const darwin: BuildContext = { goos: 'darwin', goarch: 'arm64' };
+    const linux: BuildContext = { goos: 'linux', goarch: 'amd64' };
+
+    describe('parseBuildConstraint', () => {
+        it('should read the //go:build line above the package clause', () => {
+            const text = '// Copyright notice\n\n//go:build linux && amd64\n\npackage main\n';
+            assert.strictEqual(parseBuildConstraint(text), 'linux && amd64');
+        });
+
+        it('should ignore constraints after the package clause', () => {
+            assert.strictEqual(parseBuildConstraint('package main\n\n//go:build linux\n'), undefined);
+        });
+
+        it('should convert legacy +build lines', () => {
+            const text = '// +build linux,386 darwin\n// +build !cgo\n\npackage main\n';
+            assert.strictEqual(parseBuildConstraint(text), '(linux && 386 || darwin) && !cgo');
+        });
+    });
+
+    describe('evaluateBuildConstraint', () => {
+        it('should evaluate operators and parentheses', () => {
+            assert.strictEqual(evaluateBuildConstraint('linux && (amd64 || arm64)', linux), true);
+            assert.strictEqual(evaluateBuildConstraint('linux && (amd64 || arm64)', darwin), false);
+            assert.strictEqual(evaluateBuildConstraint('!windows', darwin), true);
+            assert.strictEqual(evaluateBuildConstraint('windows || plan9', darwin), false);
+        });
+
+        it('should match derived tags', () => {
+            assert.strictEqual(evaluateBuildConstraint('unix', darwin), true);
+            assert.strictEqual(evaluateBuildConstraint('go1.21 && gc', linux), true);
+            assert.strictEqual(evaluateBuildConstraint('ignore', linux), false);
+        });
+
+        it('should treat malformed expressions as satisfied', () => {
+            assert.strictEqual(evaluateBuildConstraint('linux &&', darwin), true);
+            assert.strictEqual(evaluateBuildConstraint('(linux', darwin), true);
+        });
+    });
+
+    describe('matchesBuildContext', () => {
+        it('should honor GOOS and GOARCH file name suffixes', () => {
+            assert.strictEqual(matchesBuildContext('config_windows.go', 'package main', darwin), false);
+            assert.strictEqual(matchesBuildContext('config_darwin.go', 'package main', darwin), true);
+            assert.strictEqual(matchesBuildContext('config_linux_amd64.go', 'package main', darwin), false);
+            assert.strictEqual(matchesBuildContext('config_darwin_arm64_test.go', 'package main', darwin), true);
+            assert.strictEqual(matchesBuildContext('windows.go', 'package main', darwin), true);
+        });
+
+        it('should combine the file name with the build constraint', () => {
+            assert.strictEqual(matchesBuildContext('config.go', '//go:build windows\n\npackage main', darwin), false);
+            assert.strictEqual(matchesBuildContext('config_darwin.go', '//go:build ignore\n\npackage main', darwin), false);
+        });
+    });
+});
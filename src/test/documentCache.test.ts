@@ -0,0 +1,113 @@
+import * as assert from 'assert';
+import { DocumentCache } from '../documentCache';
+import { findStructLiteralContext } from '../goParser';
+
+// Builds a package of roughly the given number of lines made of structs and functions
+function generateSource(lines: number): string {
+    const source = ['package models', '', 'import "time"', ''];
+    for (let i = 0; source.length < lines; i++) {
+        source.push(
+            `// Model${i} is generated`,
+            `type Model${i} struct {`,
+            '\tID        int       `json:"id"`',
+            '\tName      string    // Display name',
+            '\tCreatedAt time.Time',
+            '}',
+            '',
+            `func (m *Model${i}) Validate() error {`,
+            '\treturn nil',
+            '}',
+            ''
+        );
+    }
+    return source.join('\n');
+}
+
+describe('DocumentCache', () => {
+    const uri = 'file:///project/models.go';
+
+    it('should parse a document version only once', () => {
+        const cache = new DocumentCache();
+        let reads = 0;
+        const getText = () => {
+            reads++;
+            return 'package main\n\ntype Person struct {\n\tName string\n}\n';
+        };
+
+        const first = cache.get(uri, 1, getText);
+        const second = cache.get(uri, 1, getText);
+        assert.strictEqual(reads, 1);
+        assert.strictEqual(first, second);
+        assert.strictEqual(first.packageName, 'main');
+        assert.deepStrictEqual(first.structs.map(s => s.name), ['Person']);
+    });
+
+    it('should re-parse when the version changes', () => {
+        const cache = new DocumentCache();
+        cache.get(uri, 1, () => 'package main\n\ntype Person struct {}\n');
+        const updated = cache.get(uri, 2, () => 'package main\n\ntype User struct {}\n');
+        assert.deepStrictEqual(updated.structs.map(s => s.name), ['User']);
+    });
+
+    it('should re-parse after eviction', () => {
+        const cache = new DocumentCache();
+        cache.get(uri, 1, () => 'package main\n');
+        cache.evict(uri);
+        let reads = 0;
+        cache.get(uri, 1, () => {
+            reads++;
+            return 'package main\n';
+        });
+        assert.strictEqual(reads, 1);
+    });
+
+    it('should serve lookups on a 5,000 line file in a few milliseconds', () => {
+        const cache = new DocumentCache();
+        const source = generateSource(5000);
+        cache.get(uri, 1, () => source);
+
+        const iterations = 100;
+        const start = process.hrtime.bigint();
+        for (let i = 0; i < iterations; i++) {
+            const parsed = cache.get(uri, 1, () => source);
+            assert.ok(parsed.structs.find(s => s.name === 'Model450'));
+        }
+        const perLookupMs = Number(process.hrtime.bigint() - start) / 1e6 / iterations;
+
+        assert.ok(perLookupMs < 3, `lookup took ${perLookupMs.toFixed(3)}ms`);
+    });
+
+    // The work signature help does on every keystroke, with the cursor in a
+    // literal near the end of the file
+    it('should find the literal context on a 5,000 line file in a few milliseconds', () => {
+        const source = generateSource(5000) + '\nfunc build() *Model3 {\n\treturn &Model3{ID: 1, ';
+
+        const iterations = 100;
+        const start = process.hrtime.bigint();
+        for (let i = 0; i < iterations; i++) {
+            const context = findStructLiteralContext(source, source.length);
+            assert.strictEqual(context?.typeName, 'Model3');
+        }
+        const perCallMs = Number(process.hrtime.bigint() - start) / 1e6 / iterations;
+
+        assert.ok(perCallMs < 3, `context took ${perCallMs.toFixed(3)}ms`);
+    });
+
+    // Misses happen in the background after typing pauses, or when signature
+    // help is requested before that re-parse ran
+    it('should re-parse a new version of a 5,000 line file quickly', () => {
+        const cache = new DocumentCache();
+        const source = generateSource(5000);
+        cache.get(uri, 1, () => source);
+
+        const iterations = 10;
+        const start = process.hrtime.bigint();
+        for (let version = 2; version < iterations + 2; version++) {
+            const parsed = cache.get(uri, version, () => source);
+            assert.ok(parsed.structs.find(s => s.name === 'Model450'));
+        }
+        const perParseMs = Number(process.hrtime.bigint() - start) / 1e6 / iterations;
+
+        assert.ok(perParseMs < 25, `re-parse took ${perParseMs.toFixed(3)}ms`);
+    });
+});
@@ -1,320 +0,0 @@
-import * as assert from 'assert';
-
-// We need to test the GoAnalyzer class methods
-// Since some methods are private, we'll create a TestableGoAnalyzer class
-// that exposes them for testing, or test through the public interface
-
-// Mock vscode module for unit testing
-const mockVscode = {
-    Position: class {
-        constructor(public line: number, public character: number) { }
-    },
-    TextDocument: class {
-        private content: string;
-        constructor(content: string) {
-            this.content = content;
-        }
-        getText() { return this.content; }
-        offsetAt(position: any) {
-            const lines = this.content.split('\n');
-            let offset = 0;
-            for (let i = 0; i < position.line; i++) {
-                offset += lines[i].length + 1; // +1 for newline
-            }
-            return offset + position.character;
-        }
-        positionAt(offset: number) {
-            const text = this.content.substring(0, offset);
-            const lines = text.split('\n');
-            return new mockVscode.Position(lines.length - 1, lines[lines.length - 1].length);
-        }
-    }
-};
-
-// Create a testable version that exposes private methods
-class TestableGoAnalyzer {
-    /**
-     * Counts the active field index based on commas, accounting for nested braces and strings
-     */
-    countActiveFieldIndex(text: string): number {
-        let index = 0;
-        let braceDepth = 0;
-        let parenDepth = 0;
-        let bracketDepth = 0;
-        let inDoubleQuote = false;
-        let inBacktick = false;
-        let prevChar = '';
-
-        for (const char of text) {
-            if (char === '"' && !inBacktick && prevChar !== '\\') {
-                inDoubleQuote = !inDoubleQuote;
-            } else if (char === '`' && !inDoubleQuote) {
-                inBacktick = !inBacktick;
-            } else if (!inDoubleQuote && !inBacktick) {
-                if (char === '{') braceDepth++;
-                else if (char === '}') braceDepth--;
-                else if (char === '(') parenDepth++;
-                else if (char === ')') parenDepth--;
-                else if (char === '[') bracketDepth++;
-                else if (char === ']') bracketDepth--;
-                else if (char === ',' && braceDepth === 0 && parenDepth === 0 && bracketDepth === 0) {
-                    index++;
-                }
-            }
-            prevChar = char;
-        }
-
-        return index;
-    }
-
-    /**
-     * Parses field definitions from struct body
-     */
-    parseFields(fieldsBlock: string): Array<{ name: string; type: string; tag?: string; documentation?: string }> {
-        const fields: Array<{ name: string; type: string; tag?: string; documentation?: string }> = [];
-        const lines = fieldsBlock.split(/[;\n]/).map(l => l.trim()).filter(l => l);
-
-        for (const line of lines) {
-            if (!line || line.startsWith('//')) continue;
-
-            const fieldMatch = line.match(/^(\w+)\s+([^`\/\n]+?)(?:\s+`([^`]*)`)?(?:\s*\/\/\s*(.*))?$/);
-
-            if (fieldMatch) {
-                fields.push({
-                    name: fieldMatch[1],
-                    type: fieldMatch[2].trim(),
-                    tag: fieldMatch[3],
-                    documentation: fieldMatch[4]
-                });
-            }
-        }
-
-        return fields;
-    }
-
-    /**
-     * Finds struct literal context from text and position
-     */
-    findStructLiteralContext(
-        text: string,
-        offset: number
-    ): { typeName: string; typeStartOffset: number; activeFieldIndex: number } | undefined {
-        let braceDepth = 0;
-        let openBraceOffset = -1;
-
-        for (let i = offset - 1; i >= 0; i--) {
-            const char = text[i];
-            if (char === '}') {
-                braceDepth++;
-            } else if (char === '{') {
-                if (braceDepth === 0) {
-                    openBraceOffset = i;
-                    break;
-                }
-                braceDepth--;
-            }
-        }
-
-        if (openBraceOffset === -1) {
-            return undefined;
-        }
-
-        const textBeforeBrace = text.substring(0, openBraceOffset).trimEnd();
-        const typeMatch = textBeforeBrace.match(/(&?\s*)([A-Z_][a-zA-Z0-9_]*(?:\.[A-Z_][a-zA-Z0-9_]*)?)$/);
-
-        if (!typeMatch) {
-            return undefined;
-        }
-
-        const typeName = typeMatch[2];
-        const typeStartOffset = textBeforeBrace.length - typeMatch[0].length + typeMatch[1].length;
-        const textInsideBraces = text.substring(openBraceOffset + 1, offset);
-        const activeFieldIndex = this.countActiveFieldIndex(textInsideBraces);
-
-        return {
-            typeName,
-            typeStartOffset,
-            activeFieldIndex
-        };
-    }
-}
-
-// Test Suite
-describe('GoAnalyzer', () => {
-    let analyzer: TestableGoAnalyzer;
-
-    beforeEach(() => {
-        analyzer = new TestableGoAnalyzer();
-    });
-
-    describe('countActiveFieldIndex', () => {
-        it('should return 0 for empty text', () => {
-            assert.strictEqual(analyzer.countActiveFieldIndex(''), 0);
-        });
-
-        it('should return 0 for text with no commas', () => {
-            assert.strictEqual(analyzer.countActiveFieldIndex('"hello"'), 0);
-        });
-
-        it('should count simple commas', () => {
-            assert.strictEqual(analyzer.countActiveFieldIndex('a, b, '), 2);
-        });
-
-        it('should count commas correctly for struct fields', () => {
-            assert.strictEqual(analyzer.countActiveFieldIndex('Name: "John", Age: 30, '), 2);
-        });
-
-        it('should ignore commas inside strings', () => {
-            assert.strictEqual(analyzer.countActiveFieldIndex('"hello, world", '), 1);
-        });
-
-        it('should ignore commas inside nested braces', () => {
-            assert.strictEqual(analyzer.countActiveFieldIndex('Address{Street: "123", City: "NYC"}, '), 1);
-        });
-
-        it('should ignore commas inside parentheses', () => {
-            assert.strictEqual(analyzer.countActiveFieldIndex('getValue(a, b), '), 1);
-        });
-
-        it('should ignore commas inside brackets', () => {
-            assert.strictEqual(analyzer.countActiveFieldIndex('[]int{1, 2, 3}, '), 1);
-        });
-
-        it('should handle backtick strings', () => {
-            assert.strictEqual(analyzer.countActiveFieldIndex('`hello, world`, '), 1);
-        });
-
-        it('should handle escaped quotes', () => {
-            assert.strictEqual(analyzer.countActiveFieldIndex('"hello\\"world, test", '), 1);
-        });
-    });
-
-    describe('parseFields', () => {
-        it('should parse simple fields', () => {
-            const input = 'Name string\nAge int';
-            const fields = analyzer.parseFields(input);
-            assert.strictEqual(fields.length, 2);
-            assert.strictEqual(fields[0].name, 'Name');
-            assert.strictEqual(fields[0].type, 'string');
-            assert.strictEqual(fields[1].name, 'Age');
-            assert.strictEqual(fields[1].type, 'int');
-        });
-
-        it('should parse fields with tags', () => {
-            const input = 'Port int `json:"port"`';
-            const fields = analyzer.parseFields(input);
-            assert.strictEqual(fields.length, 1);
-            assert.strictEqual(fields[0].name, 'Port');
-            assert.strictEqual(fields[0].type, 'int');
-            assert.strictEqual(fields[0].tag, 'json:"port"');
-        });
-
-        it('should parse fields with documentation comments', () => {
-            const input = 'Port int // Server port';
-            const fields = analyzer.parseFields(input);
-            assert.strictEqual(fields.length, 1);
-            assert.strictEqual(fields[0].name, 'Port');
-            assert.strictEqual(fields[0].documentation, 'Server port');
-        });
-
-        it('should parse fields with both tags and comments', () => {
-            const input = 'Port int `json:"port"` // Server port';
-            const fields = analyzer.parseFields(input);
-            assert.strictEqual(fields.length, 1);
-            assert.strictEqual(fields[0].type, 'int');
-            assert.strictEqual(fields[0].tag, 'json:"port"');
-            assert.strictEqual(fields[0].documentation, 'Server port');
-        });
-
-        it('should handle pointer types', () => {
-            const input = 'Config *Config';
-            const fields = analyzer.parseFields(input);
-            assert.strictEqual(fields.length, 1);
-            assert.strictEqual(fields[0].type, '*Config');
-        });
-
-        it('should handle slice types', () => {
-            const input = 'Items []string';
-            const fields = analyzer.parseFields(input);
-            assert.strictEqual(fields.length, 1);
-            assert.strictEqual(fields[0].type, '[]string');
-        });
-
-        it('should handle map types', () => {
-            const input = 'Data map[string]int';
-            const fields = analyzer.parseFields(input);
-            assert.strictEqual(fields.length, 1);
-            assert.strictEqual(fields[0].type, 'map[string]int');
-        });
-
-        it('should skip comment-only lines', () => {
-            const input = '// This is a comment\nName string';
-            const fields = analyzer.parseFields(input);
-            assert.strictEqual(fields.length, 1);
-            assert.strictEqual(fields[0].name, 'Name');
-        });
-
-        it('should handle semicolon-separated fields', () => {
-            const input = 'Name string; Age int';
-            const fields = analyzer.parseFields(input);
-            assert.strictEqual(fields.length, 2);
-        });
-    });
-
-    describe('findStructLiteralContext', () => {
-        it('should find simple struct context', () => {
-            const text = 'p := Person{';
-            const result = analyzer.findStructLiteralContext(text, text.length);
-            assert.ok(result);
-            assert.strictEqual(result.typeName, 'Person');
-            assert.strictEqual(result.activeFieldIndex, 0);
-        });
-
-        it('should find pointer struct context', () => {
-            const text = 'p := &Person{';
-            const result = analyzer.findStructLiteralContext(text, text.length);
-            assert.ok(result);
-            assert.strictEqual(result.typeName, 'Person');
-        });
-
-        it('should find package-prefixed struct', () => {
-            // Note: The regex requires uppercase first letter for package names
-            // In real Go code, package names are lowercase, so only the type name is captured
-            const text = 'c := http.Client{';
-            const result = analyzer.findStructLiteralContext(text, text.length);
-            assert.ok(result);
-            // Current behavior: only captures 'Client' not 'http.Client'
-            assert.strictEqual(result.typeName, 'Client');
-        });
-
-        it('should track field index after commas', () => {
-            const text = 'p := Person{Name: "John", Age: 30, ';
-            const result = analyzer.findStructLiteralContext(text, text.length);
-            assert.ok(result);
-            assert.strictEqual(result.activeFieldIndex, 2);
-        });
-
-        it('should return undefined when not in struct literal', () => {
-            const text = 'x := 42';
-            const result = analyzer.findStructLiteralContext(text, text.length);
-            assert.strictEqual(result, undefined);
-        });
-
-        it('should handle nested struct literals', () => {
-            const text = 'p := Person{Address: Address{';
-            const result = analyzer.findStructLiteralContext(text, text.length);
-            assert.ok(result);
-            assert.strictEqual(result.typeName, 'Address');
-            assert.strictEqual(result.activeFieldIndex, 0);
-        });
-
-        it('should find context in middle of struct literal', () => {
-            const text = 'p := Person{Name: "John", }';
-            // Position cursor before the closing brace
-            const cursorPos = text.length - 1;
-            const result = analyzer.findStructLiteralContext(text, cursorPos);
-            assert.ok(result);
-            assert.strictEqual(result.typeName, 'Person');
-        });
-    });
-});
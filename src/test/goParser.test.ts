@@ -0,0 +1,1180 @@
+import * as assert from 'assert';
+import {
+    collectPromotedFields,
+    countActiveFieldIndex,
+    explainMissingLiteral,
+    findStructLiteralContext,
+    instantiateStruct,
+    isExported,
+    jsonKey,
+    lookupTag,
+    parseFields,
+    parseImports,
+    parseStructs,
+    referencedTypeNames,
+    remainingFields,
+    splitElements
+} from '../goParser';
+
+// Test Suite
+describe('goParser', () => {
+    describe('countActiveFieldIndex', () => {
+        it('should return 0 for empty text', () => {
+            assert.strictEqual(countActiveFieldIndex(''), 0);
+        });
+
+        it('should return 0 for text with no commas', () => {
+            assert.strictEqual(countActiveFieldIndex('"hello"'), 0);
+        });
+
+        it('should count simple commas', () => {
+            assert.strictEqual(countActiveFieldIndex('a, b, '), 2);
+        });
+
+        it('should count commas correctly for struct fields', () => {
+            assert.strictEqual(countActiveFieldIndex('Name: "John", Age: 30, '), 2);
+        });
+
+        it('should ignore commas inside strings', () => {
+            assert.strictEqual(countActiveFieldIndex('"hello, world", '), 1);
+        });
+
+        it('should ignore commas inside nested braces', () => {
+            assert.strictEqual(countActiveFieldIndex('Address{Street: "123", City: "NYC"}, '), 1);
+        });
+
+        it('should ignore commas inside parentheses', () => {
+            assert.strictEqual(countActiveFieldIndex('getValue(a, b), '), 1);
+        });
+
+        it('should ignore commas inside brackets', () => {
+            assert.strictEqual(countActiveFieldIndex('[]int{1, 2, 3}, '), 1);
+        });
+
+        it('should handle backtick strings', () => {
+            assert.strictEqual(countActiveFieldIndex('`hello, world`, '), 1);
+        });
+
+        it('should handle escaped quotes', () => {
+            assert.strictEqual(countActiveFieldIndex('"hello\\"world, test", '), 1);
+        });
+
+        it('should handle escaped backslashes before a closing quote', () => {
+            assert.strictEqual(countActiveFieldIndex('"C:\\\\", "x, y", '), 2);
+        });
+
+        it('should ignore commas in rune literals', () => {
+            assert.strictEqual(countActiveFieldIndex("',', '\\'', "), 2);
+        });
+
+        it('should ignore commas inside comments', () => {
+            assert.strictEqual(countActiveFieldIndex('"John", // name, first\n\t30, /* a, b */ '), 2);
+        });
+
+        it('should highlight the second field after the first positional value', () => {
+            assert.strictEqual(countActiveFieldIndex('"Billy bob joe", '), 1);
+        });
+
+        it('should ignore commas inside several nested calls', () => {
+            assert.strictEqual(countActiveFieldIndex('f(1, 2), g(3, 4), '), 2);
+            assert.strictEqual(countActiveFieldIndex('f(g(1, 2), h(3)), '), 1);
+        });
+
+        it('should ignore commas inside nested composite literals', () => {
+            assert.strictEqual(countActiveFieldIndex('Inner{1, []int{2, 3}}, map[string]int{"a": 1, "b": 2}, '), 2);
+        });
+
+        it('should ignore commas inside generic instantiations', () => {
+            assert.strictEqual(countActiveFieldIndex('Pair[int, string]{1, "a"}, '), 1);
+            assert.strictEqual(countActiveFieldIndex('New[int, string](a, b), Map[K, V](m), '), 2);
+        });
+
+        it('should count only the enclosing literal in a nested positional field', () => {
+            const text = 'p := Point{f(1, 2), Pair[int, string]{1, "a"}, g(3, ';
+            assert.strictEqual(findStructLiteralContext(text, text.length), undefined);
+
+            const inLiteral = 'p := Point{f(1, 2), Pair[int, string]{1, "a"}, ';
+            const result = findStructLiteralContext(inLiteral, inLiteral.length);
+            assert.ok(result);
+            assert.strictEqual(result.typeName, 'Point');
+            assert.strictEqual(result.activeFieldIndex, 2);
+        });
+    });
+
+    describe('collectPromotedFields', () => {
+        const promote = (source: string, name: string) => {
+            const structs = parseStructs(source);
+            const resolve = (typeName: string) => structs.find(s => s.name === typeName);
+            return collectPromotedFields(structs.find(s => s.name === name)!, resolve)
+                .map(p => `${p.path.join('.')}.${p.field.name}`);
+        };
+
+        it('should promote fields through embedded structs transitively', () => {
+            const source = [
+                'type C struct { Deep int }',
+                'type B struct { C; Mid int }',
+                'type A struct { *B; Top int }'
+            ].join('\n');
+            assert.deepStrictEqual(promote(source, 'A'), ['B.C', 'B.Mid', 'B.C.Deep']);
+        });
+
+        it('should resolve shadowed names to the shallowest field', () => {
+            const source = [
+                'type C struct { Name string; ID int }',
+                'type B struct { C; Name string }',
+                'type A struct { B; ID string }'
+            ].join('\n');
+            assert.deepStrictEqual(promote(source, 'A'), ['B.C', 'B.Name']);
+        });
+
+        it('should not promote names that are ambiguous at the same depth', () => {
+            const source = [
+                'type Reader struct { Buffer []byte; Offset int }',
+                'type Writer struct { Buffer []byte }',
+                'type Pipe struct { Reader; Writer }'
+            ].join('\n');
+            assert.deepStrictEqual(promote(source, 'Pipe'), ['Reader.Offset']);
+        });
+
+        it('should not promote names reached through two embedding paths', () => {
+            const source = [
+                'type A struct { B; C }',
+                'type B struct { D }',
+                'type C struct { D }',
+                'type D struct { X int }'
+            ].join('\n');
+            assert.deepStrictEqual(promote(source, 'A'), []);
+        });
+
+        it('should stop at embedding cycles', () => {
+            const source = [
+                'type A struct { *B; X int }',
+                'type B struct { *A; Y int }'
+            ].join('\n');
+            assert.deepStrictEqual(promote(source, 'A'), ['B.A', 'B.Y']);
+        });
+
+        it('should not expand mutually recursive pointer fields', () => {
+            const structs = parseStructs('type A struct { B *B }\ntype B struct { A *A }');
+            assert.deepStrictEqual(structs.map(s => s.fields[0].type), ['*B', '*A']);
+            assert.deepStrictEqual(promote('type A struct { B *B }\ntype B struct { A *A }', 'A'), []);
+            assert.deepStrictEqual(promote('type Node struct { Val int; Next *Node }', 'Node'), []);
+        });
+
+        it('should limit the depth of generic embedding that grows at each level', () => {
+            const structs = parseStructs('type L[T any] struct { *L[[]T]; V T }');
+            const resolve = (typeName: string) => structs.find(s => s.name === typeName);
+            // Every level declares L and V again, which the outer struct shadows
+            assert.deepStrictEqual(collectPromotedFields(structs[0], resolve), []);
+        });
+
+        it('should promote fields of generic embedded structs', () => {
+            const source = 'type Box[T any] struct { Value T }\ntype Crate struct { Box[int]; Label string }';
+            assert.deepStrictEqual(promote(source, 'Crate'), ['Box.Value']);
+        });
+    });
+
+    describe('explainMissingLiteral', () => {
+        const explain = (text: string) => explainMissingLiteral(text, text.length);
+
+        it('should explain why the cursor is not in a struct literal', () => {
+            assert.match(explain('x := 1 // Person{'), /comment/);
+            assert.match(explain('p := Person{Name: "Jo'), /string/);
+            assert.match(explain('x := 1 + '), /not inside any braces/);
+            assert.match(explain('p := Person{Name: strings.Join('), /parentheses/);
+            assert.match(explain('func main() {'), /block or function body/);
+            assert.match(explain('func NewConfig() *Config {'), /block or function body/);
+            assert.match(explain('names := []string{'), /\[\]string literal/);
+        });
+    });
+
+    describe('instantiateStruct', () => {
+        const box = parseStructs('type Box[K comparable, V any] struct { Key K; Values []V; Other pkg.K }')[0];
+
+        it('should substitute type arguments into field types', () => {
+            const instance = instantiateStruct(box, ['string', 'Person']);
+            assert.strictEqual(instance.name, 'Box[string, Person]');
+            assert.deepStrictEqual(instance.fields.map(f => f.type), ['string', '[]Person', 'pkg.K']);
+        });
+
+        it('should keep parameter names without matching arguments', () => {
+            const instance = instantiateStruct(box, ['string']);
+            assert.deepStrictEqual(instance.fields.map(f => f.type), ['string', '[]V', 'pkg.K']);
+        });
+    });
+
+    describe('parseImports', () => {
+        it('should parse single and grouped imports with aliases', () => {
+            const source = [
+                'package main',
+                '',
+                'import "fmt"',
+                'import (',
+                '\t"net/http"',
+                '\tcfg "example.com/app/config" // local config',
+                '\t. "example.com/app/testing"',
+                '\t_ "embed"',
+                ')'
+            ].join('\n');
+            assert.deepStrictEqual(parseImports(source), [
+                { name: undefined, path: 'fmt' },
+                { name: undefined, path: 'net/http' },
+                { name: 'cfg', path: 'example.com/app/config' },
+                { name: '.', path: 'example.com/app/testing' },
+                { name: '_', path: 'embed' }
+            ]);
+        });
+    });
+
+    describe('referencedTypeNames', () => {
+        it('should return the named types in a field type', () => {
+            assert.deepStrictEqual(referencedTypeNames('map[Key][]*time.Time'), ['Key', 'time.Time']);
+            assert.deepStrictEqual(referencedTypeNames('*Address'), ['Address']);
+            assert.deepStrictEqual(referencedTypeNames('chan error'), []);
+            assert.deepStrictEqual(referencedTypeNames('[]string'), []);
+        });
+    });
+
+    describe('lookupTag', () => {
+        it('should find values by key', () => {
+            const tag = 'json:"name,omitempty" validate:"required,gt=0" regex:"\\"[a-z]+\\""';
+            assert.strictEqual(lookupTag(tag, 'json'), 'name,omitempty');
+            assert.strictEqual(lookupTag(tag, 'validate'), 'required,gt=0');
+            assert.strictEqual(lookupTag(tag, 'regex'), '"[a-z]+"');
+            assert.strictEqual(lookupTag(tag, 'yaml'), undefined);
+        });
+    });
+
+    describe('jsonKey', () => {
+        it('should use the name of the json tag', () => {
+            const key = (tag?: string) => jsonKey({ name: 'UserID', type: 'int', tag });
+            assert.strictEqual(key('json:"user_id"'), 'user_id');
+            assert.strictEqual(key('json:"user_id,omitempty" db:"uid"'), 'user_id');
+            assert.strictEqual(key('json:"-,"'), '-');
+            assert.strictEqual(key('json:"-"'), undefined);
+            assert.strictEqual(key('json:",omitempty"'), undefined);
+            assert.strictEqual(key('db:"uid"'), undefined);
+            assert.strictEqual(key(undefined), undefined);
+        });
+    });
+
+    describe('isExported', () => {
+        it('should treat names starting with an uppercase letter as exported', () => {
+            assert.strictEqual(isExported('Name'), true);
+            assert.strictEqual(isExported('Ärger'), true);
+            assert.strictEqual(isExported('name'), false);
+            assert.strictEqual(isExported('_'), false);
+            assert.strictEqual(isExported('_Name'), false);
+        });
+    });
+
+    describe('remainingFields', () => {
+        it('should leave out fields whose keys are already set', () => {
+            const fields = [
+                { name: 'Name', type: 'string' },
+                { name: 'Age', type: 'int' },
+                { name: 'Email', type: 'string' }
+            ];
+            assert.deepStrictEqual(remainingFields(fields, ['Age']).map(f => f.name), ['Name', 'Email']);
+        });
+
+        it('should leave out blank fields', () => {
+            const fields = parseFields('\tKind uint8\n\t_ [3]byte\n\tSize uint32\n\t_ struct{}');
+            assert.deepStrictEqual(remainingFields(fields, []).map(f => f.name), ['Kind', 'Size']);
+        });
+    });
+
+    describe('splitElements', () => {
+        it('should split on top-level commas only', () => {
+            assert.deepStrictEqual(
+                splitElements('Name: "a, b", Tags: []string{"x", "y"}, '),
+                ['Name: "a, b"', ' Tags: []string{"x", "y"}', ' ']
+            );
+        });
+    });
+
+    describe('parseFields', () => {
+        it('should parse simple fields', () => {
+            const input = 'Name string\nAge int';
+            const fields = parseFields(input);
+            assert.strictEqual(fields.length, 2);
+            assert.strictEqual(fields[0].name, 'Name');
+            assert.strictEqual(fields[0].type, 'string');
+            assert.strictEqual(fields[1].name, 'Age');
+            assert.strictEqual(fields[1].type, 'int');
+        });
+
+        it('should parse fields with tags', () => {
+            const input = 'Port int `json:"port"`';
+            const fields = parseFields(input);
+            assert.strictEqual(fields.length, 1);
+            assert.strictEqual(fields[0].name, 'Port');
+            assert.strictEqual(fields[0].type, 'int');
+            assert.strictEqual(fields[0].tag, 'json:"port"');
+        });
+
+        it('should parse fields with documentation comments', () => {
+            const input = 'Port int // Server port';
+            const fields = parseFields(input);
+            assert.strictEqual(fields.length, 1);
+            assert.strictEqual(fields[0].name, 'Port');
+            assert.strictEqual(fields[0].documentation, 'Server port');
+        });
+
+        it('should parse fields with both tags and comments', () => {
+            const input = 'Port int `json:"port"` // Server port';
+            const fields = parseFields(input);
+            assert.strictEqual(fields.length, 1);
+            assert.strictEqual(fields[0].type, 'int');
+            assert.strictEqual(fields[0].tag, 'json:"port"');
+            assert.strictEqual(fields[0].documentation, 'Server port');
+        });
+
+        it('should read tags containing commas, equals signs, spaces and braces whole', () => {
+            const input = [
+                'Name string `json:"name,omitempty" validate:"required,gt=0"`',
+                'Age int `validate:"min=0, max=150"` // Years',
+                'Code string `regex:"^[a-z]{2,3}$" note:"a // b; c"`',
+                'Next int'
+            ].join('\n');
+            const fields = parseFields(input);
+            assert.deepStrictEqual(fields.map(f => f.name), ['Name', 'Age', 'Code', 'Next']);
+            assert.deepStrictEqual(fields.map(f => f.type), ['string', 'int', 'string', 'int']);
+            assert.strictEqual(fields[0].tag, 'json:"name,omitempty" validate:"required,gt=0"');
+            assert.strictEqual(fields[1].tag, 'validate:"min=0, max=150"');
+            assert.strictEqual(fields[1].documentation, 'Years');
+            assert.strictEqual(fields[2].tag, 'regex:"^[a-z]{2,3}$" note:"a // b; c"');
+            assert.strictEqual(fields[2].documentation, undefined);
+        });
+
+        it('should parse tags written as interpreted strings', () => {
+            const fields = parseFields('Name string "json:\\"name,omitempty\\"" // Display name\n*Base "json:\\"base\\""');
+            assert.strictEqual(fields[0].type, 'string');
+            assert.strictEqual(fields[0].tag, 'json:"name,omitempty"');
+            assert.strictEqual(fields[0].documentation, 'Display name');
+            assert.strictEqual(fields[1].embedded, true);
+            assert.strictEqual(fields[1].tag, 'json:"base"');
+        });
+
+        it('should not end a struct at a brace inside a tag', () => {
+            const structs = parseStructs('type T struct {\n\tA string `x:"}"`\n\tB int\n}\ntype U struct { C int }');
+            assert.deepStrictEqual(structs.map(s => s.fields.map(f => f.name)), [['A', 'B'], ['C']]);
+        });
+
+        it('should split fields declared together on one line', () => {
+            const input = 'Min, Max int `json:"range"` // Inclusive bounds\nX,Y,Z float64';
+            const fields = parseFields(input);
+            assert.deepStrictEqual(fields.map(f => [f.name, f.type]), [
+                ['Min', 'int'],
+                ['Max', 'int'],
+                ['X', 'float64'],
+                ['Y', 'float64'],
+                ['Z', 'float64']
+            ]);
+            assert.strictEqual(fields[1].tag, 'json:"range"');
+            assert.strictEqual(fields[1].documentation, 'Inclusive bounds');
+        });
+
+        it('should parse function-typed fields', () => {
+            const input = [
+                'OnError func(error) error',
+                'Handler func(ctx context.Context, req *Request) (*Response, error) `json:"-"` // Serves requests',
+                'Filters map[string]func(int, string) bool'
+            ].join('\n');
+            const fields = parseFields(input);
+            assert.deepStrictEqual(fields.map(f => [f.name, f.type]), [
+                ['OnError', 'func(error) error'],
+                ['Handler', 'func(ctx context.Context, req *Request) (*Response, error)'],
+                ['Filters', 'map[string]func(int, string) bool']
+            ]);
+            assert.strictEqual(fields[1].tag, 'json:"-"');
+            assert.strictEqual(fields[1].documentation, 'Serves requests');
+        });
+
+        it('should join function parameters spread over several lines', () => {
+            const input = '\tValidate func(\n\t\tctx context.Context, // request scope\n\t\tvalue string,\n\t) error\n\tPort int';
+            const fields = parseFields(input);
+            assert.deepStrictEqual(fields.map(f => [f.name, f.type]), [
+                ['Validate', 'func(ctx context.Context, value string) error'],
+                ['Port', 'int']
+            ]);
+        });
+
+        it('should parse channel-typed fields', () => {
+            const input = 'Events chan Event\nResults <-chan *Result\nJobs chan<- Job\nDone chan struct{}';
+            assert.deepStrictEqual(parseFields(input).map(f => f.type), ['chan Event', '<-chan *Result', 'chan<- Job', 'chan struct{}']);
+        });
+
+        it('should use comment lines above a field as its documentation', () => {
+            const input = "\t// Name is the user's display name\n\t// shown in the header.\n\tName string\n\tAge int";
+            const fields = parseFields(input);
+            assert.strictEqual(fields.length, 2);
+            assert.strictEqual(fields[0].documentation, "Name is the user's display name\nshown in the header.");
+            assert.strictEqual(fields[1].documentation, undefined);
+        });
+
+        it('should use a block comment above a field as its documentation', () => {
+            const input = '\t/*\n\t * Port to listen on.\n\t * Defaults to 8080.\n\t */\n\tPort int';
+            const fields = parseFields(input);
+            assert.strictEqual(fields.length, 1);
+            assert.strictEqual(fields[0].documentation, 'Port to listen on.\nDefaults to 8080.');
+        });
+
+        it('should keep parsing fields after block comments containing delimiters', () => {
+            const input = '\tA int\n\t/* skip; {\n\t   } */\n\tB string\n\tC int /* unit; } */\n\t/* { */ D bool\n\tE int';
+            const fields = parseFields(input);
+            assert.deepStrictEqual(fields.map(f => f.name), ['A', 'B', 'C', 'D', 'E']);
+            assert.deepStrictEqual(fields.map(f => f.type), ['int', 'string', 'int', 'bool', 'int']);
+            assert.strictEqual(fields[2].documentation, 'unit; }');
+            assert.strictEqual(fields[3].documentation, '{');
+        });
+
+        it('should prefer a trailing comment over the comment above', () => {
+            const input = '\t// Leading\n\tPort int // Trailing';
+            assert.strictEqual(parseFields(input)[0].documentation, 'Trailing');
+        });
+
+        it('should not attach comments separated from the field by a blank line', () => {
+            const input = '\t// Section header\n\n\tPort int';
+            assert.strictEqual(parseFields(input)[0].documentation, undefined);
+        });
+
+        it('should flag fields documented as deprecated', () => {
+            const input = '\t// Host to connect to.\n\t//\n\t// Deprecated: use Addr instead.\n\tHost string\n\tTimeout int // Deprecated: use Deadline.';
+            const fields = parseFields(input);
+            assert.strictEqual(fields[0].deprecated, true);
+            assert.strictEqual(fields[1].deprecated, true);
+            assert.strictEqual(parseFields('Port int // Not Deprecated: really')[0].deprecated, undefined);
+        });
+
+        it('should handle pointer types', () => {
+            const input = 'Config *Config';
+            const fields = parseFields(input);
+            assert.strictEqual(fields.length, 1);
+            assert.strictEqual(fields[0].type, '*Config');
+        });
+
+        it('should handle slice types', () => {
+            const input = 'Items []string';
+            const fields = parseFields(input);
+            assert.strictEqual(fields.length, 1);
+            assert.strictEqual(fields[0].type, '[]string');
+        });
+
+        it('should handle map types', () => {
+            const input = 'Data map[string]int';
+            const fields = parseFields(input);
+            assert.strictEqual(fields.length, 1);
+            assert.strictEqual(fields[0].type, 'map[string]int');
+        });
+
+        it('should parse embedded fields named after their type', () => {
+            const input = 'Person\n*Address // home\nsync.Mutex\n*pkg.Config `json:"config"`\nSalary int';
+            const fields = parseFields(input);
+            assert.deepStrictEqual(
+                fields.map(f => [f.name, f.type, f.embedded === true]),
+                [
+                    ['Person', 'Person', true],
+                    ['Address', '*Address', true],
+                    ['Mutex', 'sync.Mutex', true],
+                    ['Config', '*pkg.Config', true],
+                    ['Salary', 'int', false]
+                ]
+            );
+            assert.strictEqual(fields[1].documentation, 'home');
+            assert.strictEqual(fields[3].tag, 'json:"config"');
+        });
+
+        it('should skip comment-only lines', () => {
+            const input = '// This is a comment\nName string';
+            const fields = parseFields(input);
+            assert.strictEqual(fields.length, 1);
+            assert.strictEqual(fields[0].name, 'Name');
+        });
+
+        it('should handle semicolon-separated fields', () => {
+            const input = 'Name string; Age int';
+            const fields = parseFields(input);
+            assert.strictEqual(fields.length, 2);
+        });
+    });
+
+    describe('findStructLiteralContext', () => {
+        it('should find literals when scanning from the enclosing declaration', () => {
+            const text = '\n\nvar s = "{"\n\nfunc a() {\n\tx := `\n}`\n}\n\nfunc b() {\n\tp := Person{Name: "a", ';
+            const result = findStructLiteralContext(text, text.length);
+            assert.ok(result);
+            assert.strictEqual(result.typeName, 'Person');
+            assert.strictEqual(result.typeStartOffset, text.indexOf('Person'));
+            assert.strictEqual(result.activeFieldIndex, 1);
+
+            const top = '\n\nvar c = Config{';
+            assert.strictEqual(findStructLiteralContext(top, top.length)?.typeName, 'Config');
+        });
+
+        it('should find the type of a literal far from the start of the block', () => {
+            const padding = '\tx := 1\n'.repeat(500);
+            const text = `func a() {\n${padding}\tp := &pkg.Config{`;
+            const result = findStructLiteralContext(text, text.length);
+            assert.ok(result);
+            assert.strictEqual(result.typeName, 'pkg.Config');
+            assert.strictEqual(result.typeStartOffset, text.lastIndexOf('pkg.Config'));
+        });
+
+        it('should count blank fields as positions in positional literals', () => {
+            const fields = parseFields('\tKind uint8\n\t_ [3]byte\n\tSize uint32');
+            assert.deepStrictEqual(fields.map(f => f.name), ['Kind', '_', 'Size']);
+
+            const input = 'h := Header{1, [3]byte{}, ';
+            const result = findStructLiteralContext(input, input.length);
+            assert.ok(result);
+            assert.strictEqual(result.keyed, false);
+            assert.strictEqual(fields[result.activeFieldIndex].name, 'Size');
+        });
+
+        it('should find simple struct context', () => {
+            const text = 'p := Person{';
+            const result = findStructLiteralContext(text, text.length);
+            assert.ok(result);
+            assert.strictEqual(result.typeName, 'Person');
+            assert.strictEqual(result.activeFieldIndex, 0);
+        });
+
+        it('should find pointer struct context', () => {
+            const text = 'p := &Person{';
+            const result = findStructLiteralContext(text, text.length);
+            assert.ok(result);
+            assert.strictEqual(result.typeName, 'Person');
+        });
+
+        it('should skip whitespace between & and the type name', () => {
+            const text = 'c := & Config{';
+            const result = findStructLiteralContext(text, text.length);
+            assert.ok(result);
+            assert.strictEqual(result.typeName, 'Config');
+            assert.strictEqual(result.typeStartOffset, text.indexOf('Config'));
+        });
+
+        it('should point at the type name rather than the & for pointer literals', () => {
+            const text = 'c := &Config{DatabaseURL: "postgres://localhost", ';
+            const result = findStructLiteralContext(text, text.length);
+            assert.ok(result);
+            assert.strictEqual(result.typeName, 'Config');
+            assert.strictEqual(result.typeStartOffset, text.indexOf('Config'));
+            assert.strictEqual(result.activeFieldIndex, 1);
+        });
+
+        it('should find package-prefixed struct', () => {
+            const text = 'c := http.Client{';
+            const result = findStructLiteralContext(text, text.length);
+            assert.ok(result);
+            assert.strictEqual(result.typeName, 'http.Client');
+            assert.strictEqual(result.typeStartOffset, text.indexOf('http'));
+        });
+
+        it('should combine the & and the package qualifier', () => {
+            const cases: [string, string, number][] = [
+                ['c := pkg.Config{', 'pkg.Config', 5],
+                ['c := &pkg.Config{', 'pkg.Config', 6],
+                ['c := &pkg.Config{Name: "a", ', 'pkg.Config', 6],
+                ['c := []*pkg.Config{&pkg.Config{', 'pkg.Config', 20],
+                ['c := (*pkg.Config)(nil); d := &pkg.Config{', 'pkg.Config', 31],
+                ['c := &Config{', 'Config', 6]
+            ];
+            for (const [text, typeName, typeStartOffset] of cases) {
+                const result = findStructLiteralContext(text, text.length);
+                assert.ok(result, text);
+                assert.strictEqual(result.typeName, typeName, text);
+                assert.strictEqual(result.typeStartOffset, typeStartOffset, text);
+            }
+        });
+
+        it('should track field index after commas', () => {
+            const text = 'p := Person{Name: "John", Age: 30, ';
+            const result = findStructLiteralContext(text, text.length);
+            assert.ok(result);
+            assert.strictEqual(result.activeFieldIndex, 2);
+        });
+
+        it('should return undefined when not in struct literal', () => {
+            const text = 'x := 42';
+            const result = findStructLiteralContext(text, text.length);
+            assert.strictEqual(result, undefined);
+        });
+
+        it('should handle nested struct literals', () => {
+            const text = 'p := Person{Address: Address{';
+            const result = findStructLiteralContext(text, text.length);
+            assert.ok(result);
+            assert.strictEqual(result.typeName, 'Address');
+            assert.strictEqual(result.activeFieldIndex, 0);
+        });
+
+        it('should find context in middle of struct literal', () => {
+            const text = 'p := Person{Name: "John", }';
+            // Position cursor before the closing brace
+            const cursorPos = text.length - 1;
+            const result = findStructLiteralContext(text, cursorPos);
+            assert.ok(result);
+            assert.strictEqual(result.typeName, 'Person');
+        });
+
+        it('should handle arbitrarily deep nesting', () => {
+            const text = 'o := Order{Customer: Person{Address: Address{Street: "x", ';
+            const result = findStructLiteralContext(text, text.length);
+            assert.ok(result);
+            assert.strictEqual(result.typeName, 'Address');
+            assert.strictEqual(result.activeFieldIndex, 1);
+        });
+
+        it('should pop back to the outer struct once the inner literal closes', () => {
+            const text = 'p := Person{Name: "John", Address: Address{Street: "x", City: "y"}, ';
+            const result = findStructLiteralContext(text, text.length);
+            assert.ok(result);
+            assert.strictEqual(result.typeName, 'Person');
+            assert.strictEqual(result.activeFieldIndex, 2);
+        });
+
+        it('should ignore braces inside strings and comments', () => {
+            const text = 'p := Person{Name: "}", // }\n\tAddress: Address{City: `}`}, ';
+            const result = findStructLiteralContext(text, text.length);
+            assert.ok(result);
+            assert.strictEqual(result.typeName, 'Person');
+        });
+
+        it('should infer the element type inside a slice literal', () => {
+            const text = 'people := []Person{ {Name: "a"}, {';
+            const result = findStructLiteralContext(text, text.length);
+            assert.ok(result);
+            assert.strictEqual(result.typeName, 'Person');
+            assert.strictEqual(result.typeStartOffset, text.indexOf('Person'));
+        });
+
+        it('should infer the element type for the first element', () => {
+            const text = 'people := []Person{{Name: "a", ';
+            const result = findStructLiteralContext(text, text.length);
+            assert.ok(result);
+            assert.strictEqual(result.typeName, 'Person');
+            assert.strictEqual(result.activeFieldIndex, 1);
+        });
+
+        it('should infer the element type inside an array literal', () => {
+            const text = 'stops := [3]Address{ {Street: "x"}, {';
+            const result = findStructLiteralContext(text, text.length);
+            assert.ok(result);
+            assert.strictEqual(result.typeName, 'Address');
+        });
+
+        it('should infer the element type of arrays with ellipsis or expression lengths', () => {
+            const cases: [string, string][] = [
+                ['stops := [...]Address{{', 'Address'],
+                ['stops := [N]Address{{Street: "x"}, {', 'Address'],
+                ['stops := [2*N+1]geo.Address{{', 'geo.Address'],
+                ['stops := [len(names)]Address{{', 'Address'],
+                ['grid := [3][2]Point{{{', 'Point']
+            ];
+            for (const [text, typeName] of cases) {
+                const result = findStructLiteralContext(text, text.length);
+                assert.ok(result, text);
+                assert.strictEqual(result.typeName, typeName, text);
+            }
+        });
+
+        it('should not treat the array literal itself as a struct', () => {
+            const text = 'stops := [...]Address{';
+            assert.strictEqual(findStructLiteralContext(text, text.length), undefined);
+        });
+
+        it('should infer element types through nested slices', () => {
+            const text = 'grid := [][]Point{ {{1, 2}}, {{';
+            const result = findStructLiteralContext(text, text.length);
+            assert.ok(result);
+            assert.strictEqual(result.typeName, 'Point');
+        });
+
+        it('should infer the value type inside a map literal', () => {
+            const text = 'envs := map[string]Config{ "prod": {';
+            const result = findStructLiteralContext(text, text.length);
+            assert.ok(result);
+            assert.strictEqual(result.typeName, 'Config');
+            assert.strictEqual(result.typeStartOffset, text.indexOf('Config'));
+        });
+
+        it('should infer the value type after earlier map entries', () => {
+            const text = 'envs := map[string]Config{\n\t"dev": {Port: 1},\n\t"prod": {Port: 2, ';
+            const result = findStructLiteralContext(text, text.length);
+            assert.ok(result);
+            assert.strictEqual(result.typeName, 'Config');
+            assert.strictEqual(result.activeFieldIndex, 1);
+        });
+
+        it('should infer the key type when the key is an elided struct literal', () => {
+            const text = 'names := map[Point]string{ {X: 1, Y: 2}: "a", {';
+            const result = findStructLiteralContext(text, text.length);
+            assert.ok(result);
+            assert.strictEqual(result.typeName, 'Point');
+            assert.strictEqual(result.typeStartOffset, text.indexOf('Point'));
+        });
+
+        it('should infer the value type of a map nested in a slice', () => {
+            const text = 'x := []map[string]Config{ {"a": {';
+            const result = findStructLiteralContext(text, text.length);
+            assert.ok(result);
+            assert.strictEqual(result.typeName, 'Config');
+        });
+
+        it('should infer elements of slices and maps set as field values', () => {
+            const cases: [string, string, number][] = [
+                ['s := Server{ Routes: []Route{ {', 'Route', 0],
+                ['s := Server{ Routes: []Route{ {Path: "/"}, {Method: "GET", ', 'Route', 1],
+                ['s := Server{Name: "x", Routes: []Route{ {Handlers: []Handler{ {', 'Handler', 0],
+                ['s := []Server{ {Routes: map[string]*Route{"home": {Path: "/", ', 'Route', 1],
+                ['s := Server{ Routes: []Route{ {Path: "/"} }, TLS: &TLSConfig{', 'TLSConfig', 0]
+            ];
+            for (const [text, typeName, activeFieldIndex] of cases) {
+                const result = findStructLiteralContext(text, text.length);
+                assert.ok(result, text);
+                assert.strictEqual(result.typeName, typeName, text);
+                assert.strictEqual(result.activeFieldIndex, activeFieldIndex, text);
+            }
+        });
+
+        it('should return to the outer struct after a nested slice closes', () => {
+            const text = 's := Server{ Routes: []Route{ {Path: "/"} }, ';
+            const result = findStructLiteralContext(text, text.length);
+            assert.ok(result);
+            assert.strictEqual(result.typeName, 'Server');
+            assert.deepStrictEqual(result.usedKeys, ['Routes']);
+        });
+
+        it('should dereference pointer element types', () => {
+            const cases: [string, string][] = [
+                ['people := []*Person{ {', 'Person'],
+                ['people := []*Person{{Name: "a"}, {', 'Person'],
+                ['points := [3]*Point{{', 'Point'],
+                ['envs := map[string]*Config{ "k": {', 'Config'],
+                ['envs := map[string]*pkg.Config{"k": {', 'pkg.Config'],
+                ['grid := [][]*Cell{{{', 'Cell']
+            ];
+            for (const [text, typeName] of cases) {
+                const result = findStructLiteralContext(text, text.length);
+                assert.ok(result, text);
+                assert.strictEqual(result.typeName, typeName, text);
+                assert.strictEqual(result.typeStartOffset, text.lastIndexOf(typeName), text);
+            }
+        });
+
+        it('should dereference pointers to anonymous struct elements', () => {
+            const text = 'rows := []*struct{ ID int; Name string }{ {';
+            const result = findStructLiteralContext(text, text.length);
+            assert.ok(result);
+            assert.deepStrictEqual(result.inlineStruct?.fields.map(f => f.name), ['ID', 'Name']);
+        });
+
+        it('should return undefined directly inside a map literal', () => {
+            const text = 'envs := map[string]Config{';
+            const result = findStructLiteralContext(text, text.length);
+            assert.strictEqual(result, undefined);
+        });
+
+        it('should return undefined directly inside a slice literal', () => {
+            const text = 'people := []Person{';
+            const result = findStructLiteralContext(text, text.length);
+            assert.strictEqual(result, undefined);
+        });
+
+        it('should return undefined for an untyped brace in a block', () => {
+            const text = 'func main() {\n\t{';
+            const result = findStructLiteralContext(text, text.length);
+            assert.strictEqual(result, undefined);
+        });
+
+        it('should not match a type name that ends another identifier', () => {
+            for (const text of ['x := serverConfig{', 'x := a.b.Config{', 'x := my_Config{']) {
+                assert.strictEqual(findStructLiteralContext(text, text.length), undefined, text);
+            }
+        });
+
+        it('should split type arguments from a generic instantiation', () => {
+            const text = 'b := Box[int]{';
+            const result = findStructLiteralContext(text, text.length);
+            assert.ok(result);
+            assert.strictEqual(result.typeName, 'Box');
+            assert.deepStrictEqual(result.typeArgs, ['int']);
+            assert.strictEqual(result.typeStartOffset, text.indexOf('Box'));
+        });
+
+        it('should handle nested brackets in type arguments', () => {
+            const text = 'p := pkg.Pair[string, []int]{';
+            const result = findStructLiteralContext(text, text.length);
+            assert.ok(result);
+            assert.strictEqual(result.typeName, 'pkg.Pair');
+            assert.deepStrictEqual(result.typeArgs, ['string', '[]int']);
+        });
+
+        it('should infer generic element types', () => {
+            const text = 'boxes := []Box[string]{ {';
+            const result = findStructLiteralContext(text, text.length);
+            assert.ok(result);
+            assert.strictEqual(result.typeName, 'Box');
+            assert.deepStrictEqual(result.typeArgs, ['string']);
+        });
+
+        it('should detect keyed literals and collect the keys already set', () => {
+            const text = 'p := Person{Name: "John", Age: 30, ';
+            const result = findStructLiteralContext(text, text.length);
+            assert.ok(result);
+            assert.strictEqual(result.keyed, true);
+            assert.deepStrictEqual(result.usedKeys, ['Name', 'Age']);
+            assert.strictEqual(result.activeKey, undefined);
+        });
+
+        it('should report the key of the element at the cursor', () => {
+            const text = 'p := Person{Name: "John", Email: ';
+            const result = findStructLiteralContext(text, text.length);
+            assert.ok(result);
+            assert.deepStrictEqual(result.usedKeys, ['Name']);
+            assert.strictEqual(result.activeKey, 'Email');
+        });
+
+        it('should treat literals without a key in the first element as positional', () => {
+            const text = 'p := Person{"John", 30, ';
+            const result = findStructLiteralContext(text, text.length);
+            assert.ok(result);
+            assert.strictEqual(result.keyed, false);
+            assert.deepStrictEqual(result.usedKeys, []);
+        });
+
+        it('should not mistake colons inside nested values for keys', () => {
+            const text = 'p := Person{"John", Address{City: "Boston"}, ';
+            const result = findStructLiteralContext(text, text.length);
+            assert.ok(result);
+            assert.strictEqual(result.keyed, false);
+        });
+
+        it('should expect a key at the start of an element in keyed and empty literals', () => {
+            for (const text of ['p := Person{', 'p := Person{Name: "John", ', 'p := Person{Name: "John", Ag']) {
+                const result = findStructLiteralContext(text, text.length);
+                assert.ok(result);
+                assert.strictEqual(result.expectingKey, true, text);
+            }
+        });
+
+        it('should not expect a key in values or positional literals', () => {
+            for (const text of ['p := Person{Name: ', 'p := Person{"John", ']) {
+                const result = findStructLiteralContext(text, text.length);
+                assert.ok(result);
+                assert.strictEqual(result.expectingKey, false, text);
+            }
+        });
+
+        it('should parse the fields of an anonymous struct literal', () => {
+            const text = 'point := struct{ X, Y int; Label string }{X: 1, ';
+            const result = findStructLiteralContext(text, text.length);
+            assert.ok(result);
+            assert.strictEqual(result.typeName, 'struct');
+            assert.ok(result.inlineStruct);
+            assert.deepStrictEqual(result.inlineStruct.fields.map(f => f.name), ['X', 'Y', 'Label']);
+            assert.deepStrictEqual(result.usedKeys, ['X']);
+        });
+
+        it('should infer anonymous struct elements of table-driven tests', () => {
+            const text = [
+                'tests := []struct {',
+                '\tname string',
+                '\twant map[string]int',
+                '}{',
+                '\t{name: "empty", want: map[string]int{}},',
+                '\t{'
+            ].join('\n');
+            const result = findStructLiteralContext(text, text.length);
+            assert.ok(result);
+            assert.ok(result.inlineStruct);
+            assert.deepStrictEqual(result.inlineStruct.fields.map(f => [f.name, f.type]), [['name', 'string'], ['want', 'map[string]int']]);
+        });
+
+        it('should not mistake function bodies and blocks after a type for literals', () => {
+            const texts = [
+                'func NewConfig() *Config {',
+                'func NewConfig() *Config {\n\t',
+                'func (s *Server) Settings() pkg.Config {',
+                'func Configs() []Config {',
+                'fn := func() Config {',
+                'for _, c := range Configs {',
+                'switch Mode {'
+            ];
+            for (const text of texts) {
+                assert.strictEqual(findStructLiteralContext(text, text.length), undefined, text);
+            }
+
+            const text = 'func NewConfig() *Config {\n\treturn &Config{';
+            assert.strictEqual(findStructLiteralContext(text, text.length)?.typeName, 'Config');
+        });
+
+        it('should not mistake a closed block for an anonymous struct type', () => {
+            const text = 'if ok {} else {}{';
+            assert.strictEqual(findStructLiteralContext(text, text.length), undefined);
+        });
+
+        it('should return undefined inside comments and strings', () => {
+            const texts = [
+                '// Person{',
+                'p := Person{Name: "John", // Address{',
+                'p := Person{Name: "Person{',
+                'p := Person{Name: "say \\"hi\\"',
+                'p := Person{Name: `multi\nline Address{',
+                'p := Person{ /* Address{ ',
+                'p := Person{ /* open\n block Address{',
+                'package main\n\n/*\nfunc example() {\n\tp := Person{',
+                'package main\n\nvar usage = `\nfunc example() {\n\tp := Person{'
+            ];
+            for (const text of texts) {
+                assert.strictEqual(findStructLiteralContext(text, text.length), undefined, text);
+            }
+        });
+
+        it('should find the literal right after a closed string or comment', () => {
+            for (const text of ['p := Person{Name: "John"', 'p := Person{Name: "C:\\\\"', 'p := Person{ /* note */', 'p := Person{ // note\n']) {
+                const result = findStructLiteralContext(text, text.length);
+                assert.ok(result, text);
+                assert.strictEqual(result.typeName, 'Person');
+            }
+        });
+
+        it('should find literals passed as call arguments or returned', () => {
+            const cases: [string, number][] = [
+                ['fn(a, Config{', 6],
+                ['fn(Config{', 3],
+                ['return Config{', 7],
+                ['if err := save(ctx, &Config{', 21]
+            ];
+            for (const [text, typeStart] of cases) {
+                const result = findStructLiteralContext(text, text.length);
+                assert.ok(result, text);
+                assert.strictEqual(result.typeName, 'Config', text);
+                assert.strictEqual(result.typeStartOffset, typeStart, text);
+                assert.strictEqual(result.activeFieldIndex, 0, text);
+            }
+        });
+
+        it('should not count call arguments before the literal as fields', () => {
+            const text = 'fn(a, b, Config{Name: format(x, y), ';
+            const result = findStructLiteralContext(text, text.length);
+            assert.ok(result);
+            assert.strictEqual(result.typeName, 'Config');
+            assert.strictEqual(result.activeFieldIndex, 1);
+            assert.deepStrictEqual(result.usedKeys, ['Name']);
+        });
+
+        it('should return undefined inside a call nested in the literal', () => {
+            const text = 'p := Person{Name: getName(';
+            const result = findStructLiteralContext(text, text.length);
+            assert.strictEqual(result, undefined);
+        });
+    });
+
+    describe('parseStructs', () => {
+        it('should parse structs declared on a single line', () => {
+            const structs = parseStructs('type P struct { A int; B int }\ntype Q struct{ Name string `json:"name"` }\ntype E struct{}');
+            assert.deepStrictEqual(structs.map(s => s.name), ['P', 'Q', 'E']);
+            assert.deepStrictEqual(structs[0].fields.map(f => `${f.name} ${f.type}`), ['A int', 'B int']);
+            assert.strictEqual(structs[1].fields[0].tag, 'json:"name"');
+            assert.deepStrictEqual(structs[2].fields, []);
+        });
+
+        it('should parse fields on the lines of the braces', () => {
+            const input = 'type Config struct { DatabaseURL string // Connection string\n\tPort int `json:"port"`\n\tDebug bool }\n\ntype Next struct {\n\tID int\n}';
+            const structs = parseStructs(input);
+            assert.deepStrictEqual(structs.map(s => s.name), ['Config', 'Next']);
+            assert.deepStrictEqual(structs[0].fields.map(f => f.name), ['DatabaseURL', 'Port', 'Debug']);
+            assert.strictEqual(structs[0].fields[0].documentation, 'Connection string');
+            assert.strictEqual(structs[0].fields[2].type, 'bool');
+        });
+
+        it('should not end a struct at a brace inside a block comment', () => {
+            const input = 'type Server struct {\n\tHost string\n\t/* } ; { */\n\tPort int\n}\n\ntype Other struct {\n\tID int\n}';
+            const structs = parseStructs(input);
+            assert.deepStrictEqual(structs.map(s => s.name), ['Server', 'Other']);
+            assert.deepStrictEqual(structs[0].fields.map(f => f.name), ['Host', 'Port']);
+        });
+
+        it('should parse every struct declaration in the source', () => {
+            const source = [
+                'package main',
+                '',
+                '// Address represents a physical location',
+                'type Address struct {',
+                '\tStreet string // Street name',
+                '\tCity   string',
+                '}',
+                '',
+                'type Person struct {',
+                '\tName    string',
+                '\tAddress Address',
+                '}'
+            ].join('\n');
+            const structs = parseStructs(source);
+            assert.strictEqual(structs.length, 2);
+            assert.strictEqual(structs[0].name, 'Address');
+            assert.strictEqual(structs[0].documentation, 'Address represents a physical location');
+            assert.deepStrictEqual(structs[0].fields.map(f => f.name), ['Street', 'City']);
+            assert.strictEqual(structs[1].name, 'Person');
+            assert.strictEqual(structs[1].documentation, undefined);
+            assert.strictEqual(structs[1].fields[1].type, 'Address');
+        });
+
+        it('should parse structs around code with syntax errors', () => {
+            const source = [
+                'type Address struct {',
+                '\tCity string',
+                '}',
+                '',
+                'func main() {',
+                '\tp := Person{Name: "x", ',
+                '\tif {{ (',
+                '',
+                'type Person struct {',
+                '\tName string',
+                '}'
+            ].join('\n');
+            assert.deepStrictEqual(parseStructs(source).map(s => s.name), ['Address', 'Person']);
+        });
+
+        it('should parse the fields typed so far in an unterminated struct', () => {
+            const source = [
+                'type Person struct {',
+                '\tName string',
+                '\tAge  int',
+                '',
+                'func main() {',
+                '}'
+            ].join('\n');
+            const structs = parseStructs(source);
+            assert.strictEqual(structs.length, 1);
+            assert.deepStrictEqual(structs[0].fields.map(f => f.name), ['Name', 'Age']);
+        });
+
+        it('should parse structs declared in a type group', () => {
+            const source = [
+                'type (',
+                '\tCelsius float64',
+                '',
+                '\t// Point is a location',
+                '\tPoint struct {',
+                '\t\tX, Y int',
+                '\t}',
+                '\tLine struct { A Point; B Point }',
+                ')'
+            ].join('\n');
+            const structs = parseStructs(source);
+            assert.deepStrictEqual(structs.map(s => s.name), ['Point', 'Line']);
+            assert.strictEqual(structs[0].documentation, 'Point is a location');
+            assert.deepStrictEqual(structs[1].fields.map(f => f.name), ['A', 'B']);
+        });
+
+        it('should keep nested struct and interface fields intact', () => {
+            const source = [
+                'type Server struct {',
+                '\tLimits struct {',
+                '\t\tMax int',
+                '\t\tMin int',
+                '\t}',
+                '\tMeta map[string]interface{} // Arbitrary metadata',
+                '\tPort int',
+                '}'
+            ].join('\n');
+            const structs = parseStructs(source);
+            assert.strictEqual(structs.length, 1);
+            assert.deepStrictEqual(
+                structs[0].fields.map(f => [f.name, f.type]),
+                [['Limits', 'struct { Max int; Min int }'], ['Meta', 'map[string]interface{}'], ['Port', 'int']]
+            );
+            assert.strictEqual(structs[0].fields[1].documentation, 'Arbitrary metadata');
+        });
+
+        it('should ignore struct declarations in comments and strings', () => {
+            const source = [
+                '// type Fake struct { A int }',
+                'var s = "type Other struct { B int }"',
+                'type Real struct { C int }'
+            ].join('\n');
+            assert.deepStrictEqual(parseStructs(source).map(s => s.name), ['Real']);
+        });
+
+        it('should parse structs declared inside functions', () => {
+            const source = 'func TestX(t *testing.T) {\n\ttype row struct {\n\t\tin string\n\t}\n}';
+            assert.deepStrictEqual(parseStructs(source).map(s => s.name), ['row']);
+        });
+
+        it('should parse the type parameters of generic structs', () => {
+            const source = [
+                'type Box[T any] struct { Value T; Tags []string }',
+                'type Pair[K comparable, V any] struct {',
+                '\tKey K',
+                '\tVal V',
+                '}'
+            ].join('\n');
+            const structs = parseStructs(source);
+            assert.deepStrictEqual(structs.map(s => s.name), ['Box', 'Pair']);
+            assert.deepStrictEqual(structs[0].typeParams, ['T']);
+            assert.deepStrictEqual(structs[0].fields.map(f => f.type), ['T', '[]string']);
+            assert.deepStrictEqual(structs[1].typeParams, ['K', 'V']);
+        });
+
+        it('should not mistake array types for type parameters', () => {
+            assert.deepStrictEqual(parseStructs('type Grid [4]struct { X int }').map(s => s.name), []);
+        });
+
+        it('should keep embedded fields in declaration order', () => {
+            const structs = parseStructs('type Employee struct { Person; Salary int }');
+            assert.strictEqual(structs.length, 1);
+            assert.deepStrictEqual(structs[0].fields.map(f => f.name), ['Person', 'Salary']);
+            assert.strictEqual(structs[0].fields[0].embedded, true);
+        });
+
+        it('should record the line each struct is declared on', () => {
+            const source = 'package main\n\ntype Address struct {\n\tCity string\n}\n\ntype (\n\tPerson struct {\n\t\tName string\n\t}\n)\n';
+            assert.deepStrictEqual(parseStructs(source).map(s => s.line), [2, 7]);
+        });
+
+        it('should record aliases and defined types with the type they name', () => {
+            const source = [
+                'type UserConfig = Config',
+                '',
+                '// Settings wraps the shared configuration',
+                'type Settings config.Config',
+                'type IntBox = Box[int]',
+                'type (',
+                '\tCelsius float64',
+                '\tPoint = struct {',
+                '\t\tX, Y int',
+                '\t}',
+                ')'
+            ].join('\n');
+            const structs = parseStructs(source);
+            assert.deepStrictEqual(
+                structs.map(s => [s.name, s.underlying]),
+                [
+                    ['UserConfig', 'Config'],
+                    ['Settings', 'config.Config'],
+                    ['IntBox', 'Box[int]'],
+                    ['Point', undefined]
+                ]
+            );
+            assert.strictEqual(structs[1].documentation, 'Settings wraps the shared configuration');
+            assert.deepStrictEqual(structs[0].fields, []);
+        });
+
+        it('should not record types whose right-hand side is not a type name', () => {
+            const source = [
+                'type Handler func(w Writer)',
+                'type Names []string',
+                'type Index map[string]int',
+                'type Grid [4]int',
+                'type Queue chan Job',
+                'type Celsius float64'
+            ].join('\n');
+            assert.deepStrictEqual(parseStructs(source), []);
+        });
+    });
+});
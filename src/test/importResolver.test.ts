@@ -0,0 +1,215 @@
+import * as assert from 'assert';
+import * as path from 'path';
+import { ImportResolver, WorkspaceResolvers, escapeModulePath, parseGoMod, parseGoWork } from '../importResolver';
+import { SourceReader } from '../packageScanner';
+
+class FakeReader implements SourceReader {
+    files = new Map<string, string>();
+
+    readDir(): string[] {
+        return [];
+    }
+
+    readFile(file: string): string | undefined {
+        return this.files.get(file);
+    }
+}
+
+describe('importResolver', () => {
+    describe('parseGoMod', () => {
+        it('should parse module, require and replace directives', () => {
+            const module = parseGoMod([
+                'module example.com/app',
+                '',
+                'go 1.21',
+                '',
+                'require github.com/pkg/errors v0.9.1',
+                'require (',
+                '\tgithub.com/BurntSushi/toml v1.3.2 // indirect',
+                '\texample.com/shared v0.0.0',
+                ')',
+                '',
+                'replace example.com/shared => ../shared',
+                'replace (',
+                '\tgithub.com/pkg/errors v0.9.1 => github.com/fork/errors v0.9.2',
+                ')'
+            ].join('\n'));
+            assert.ok(module);
+            assert.strictEqual(module.path, 'example.com/app');
+            assert.strictEqual(module.requires.get('github.com/BurntSushi/toml'), 'v1.3.2');
+            assert.deepStrictEqual(module.replaces.get('example.com/shared'), { path: '../shared', version: undefined });
+            assert.deepStrictEqual(module.replaces.get('github.com/pkg/errors'), { path: 'github.com/fork/errors', version: 'v0.9.2' });
+        });
+
+        it('should return undefined without a module directive', () => {
+            assert.strictEqual(parseGoMod('go 1.21\n'), undefined);
+        });
+    });
+
+    describe('parseGoWork', () => {
+        it('should parse use and replace directives', () => {
+            const work = parseGoWork([
+                'go 1.22',
+                '',
+                'use ./api',
+                'use (',
+                '\t./services/billing',
+                '\t"../tools"',
+                ')',
+                'replace example.com/legacy => ./vendor/legacy'
+            ].join('\n'));
+            assert.deepStrictEqual(work.uses, ['./api', './services/billing', '../tools']);
+            assert.deepStrictEqual(work.replaces.get('example.com/legacy'), { path: './vendor/legacy', version: undefined });
+        });
+    });
+
+    describe('escapeModulePath', () => {
+        it('should escape uppercase letters', () => {
+            assert.strictEqual(escapeModulePath('github.com/BurntSushi/toml'), 'github.com/!burnt!sushi/toml');
+        });
+    });
+
+    describe('ImportResolver', () => {
+        const root = path.join(path.sep, 'work', 'app');
+        const file = path.join(root, 'cmd', 'main.go');
+        const goroot = path.join(path.sep, 'usr', 'local', 'go');
+        const gomodcache = path.join(path.sep, 'home', 'me', 'go', 'pkg', 'mod');
+
+        let resolver: ImportResolver;
+
+        beforeEach(() => {
+            const reader = new FakeReader();
+            reader.files.set(path.join(root, 'go.mod'), [
+                'module example.com/app',
+                'require (',
+                '\tgithub.com/BurntSushi/toml v1.3.2',
+                '\texample.com/shared v0.0.0',
+                ')',
+                'replace example.com/shared => ../shared'
+            ].join('\n'));
+            resolver = new ImportResolver(reader, () => ({ goroot, gomodcache }));
+        });
+
+        it('should resolve packages of the current module', () => {
+            assert.strictEqual(resolver.resolvePackageDir('example.com/app/models', file), path.join(root, 'models'));
+        });
+
+        it('should resolve standard library packages under GOROOT', () => {
+            assert.strictEqual(resolver.resolvePackageDir('net/http', file), path.join(goroot, 'src', 'net', 'http'));
+        });
+
+        it('should resolve dependencies in the module cache', () => {
+            assert.strictEqual(
+                resolver.resolvePackageDir('github.com/BurntSushi/toml/internal', file),
+                path.join(gomodcache, 'github.com', '!burnt!sushi', 'toml@v1.3.2', 'internal')
+            );
+        });
+
+        it('should follow local replace directives', () => {
+            assert.strictEqual(
+                resolver.resolvePackageDir('example.com/shared/types', file),
+                path.join(path.sep, 'work', 'shared', 'types')
+            );
+        });
+
+        it('should give up on unknown non-standard packages', () => {
+            assert.strictEqual(resolver.resolvePackageDir('github.com/unknown/pkg', file), undefined);
+        });
+    });
+
+    describe('ImportResolver in vendor mode', () => {
+        const root = path.join(path.sep, 'work', 'app');
+        const file = path.join(root, 'main.go');
+        const goroot = path.join(path.sep, 'usr', 'local', 'go');
+
+        let resolver: ImportResolver;
+
+        beforeEach(() => {
+            const reader = new FakeReader();
+            reader.files.set(path.join(root, 'go.mod'), [
+                'module example.com/app',
+                'require (',
+                '\tgithub.com/BurntSushi/toml v1.3.2',
+                '\texample.com/shared v0.0.0',
+                ')',
+                'replace example.com/shared => ../shared'
+            ].join('\n'));
+            reader.files.set(path.join(root, 'vendor', 'modules.txt'), '# github.com/BurntSushi/toml v1.3.2\n## explicit\ngithub.com/BurntSushi/toml\n');
+            resolver = new ImportResolver(reader, () => ({ goroot, gomodcache: path.join(path.sep, 'cache') }));
+        });
+
+        it('should resolve dependencies from the vendor directory', () => {
+            assert.strictEqual(
+                resolver.resolvePackageDir('github.com/BurntSushi/toml', file),
+                path.join(root, 'vendor', 'github.com', 'BurntSushi', 'toml')
+            );
+            assert.strictEqual(
+                resolver.resolvePackageDir('example.com/shared/types', file),
+                path.join(root, 'vendor', 'example.com', 'shared', 'types')
+            );
+        });
+
+        it('should still resolve the module itself and the standard library', () => {
+            assert.strictEqual(resolver.resolvePackageDir('example.com/app/models', file), path.join(root, 'models'));
+            assert.strictEqual(resolver.resolvePackageDir('net/http', file), path.join(goroot, 'src', 'net', 'http'));
+        });
+    });
+
+    describe('ImportResolver with a go.work workspace', () => {
+        const workspace = path.join(path.sep, 'work');
+        const apiFile = path.join(workspace, 'api', 'handlers', 'user.go');
+
+        let reader: FakeReader;
+        let resolver: ImportResolver;
+
+        beforeEach(() => {
+            reader = new FakeReader();
+            reader.files.set(path.join(workspace, 'go.work'), 'go 1.22\n\nuse (\n\t./api\n\t./models\n)\n');
+            reader.files.set(path.join(workspace, 'api', 'go.mod'), 'module example.com/api\n\nrequire example.com/models v1.0.0\n');
+            reader.files.set(path.join(workspace, 'models', 'go.mod'), 'module example.com/models\n');
+            resolver = new ImportResolver(reader, () => ({ gomodcache: path.join(path.sep, 'cache') }));
+        });
+
+        it('should resolve packages of the other workspace modules locally', () => {
+            assert.strictEqual(
+                resolver.resolvePackageDir('example.com/models/user', apiFile),
+                path.join(workspace, 'models', 'user')
+            );
+        });
+
+        it('should fall back to the module cache after go.work is removed', () => {
+            resolver.resolvePackageDir('example.com/models/user', apiFile);
+            reader.files.delete(path.join(workspace, 'go.work'));
+            resolver.invalidate();
+            assert.strictEqual(
+                resolver.resolvePackageDir('example.com/models/user', apiFile),
+                path.join(path.sep, 'cache', 'example.com', 'models@v1.0.0', 'user')
+            );
+        });
+    });
+
+    describe('WorkspaceResolvers', () => {
+        it('should keep a resolver per workspace root', () => {
+            const rootA = path.join(path.sep, 'repos', 'a');
+            const rootB = path.join(path.sep, 'repos', 'b');
+            const created: ImportResolver[] = [];
+            const resolvers = new WorkspaceResolvers(() => [rootA, rootB], () => {
+                const reader = new FakeReader();
+                reader.files.set(path.join(rootA, 'go.mod'), 'module example.com/a\n');
+                reader.files.set(path.join(rootB, 'go.mod'), 'module example.com/b\n');
+                const resolver = new ImportResolver(reader, () => ({}));
+                created.push(resolver);
+                return resolver;
+            });
+
+            assert.strictEqual(resolvers.resolvePackageDir('example.com/a/x', path.join(rootA, 'main.go')), path.join(rootA, 'x'));
+            assert.strictEqual(resolvers.resolvePackageDir('example.com/b/y', path.join(rootB, 'main.go')), path.join(rootB, 'y'));
+            assert.strictEqual(resolvers.resolvePackageDir('example.com/a/z', path.join(rootA, 'cmd', 'main.go')), path.join(rootA, 'z'));
+            assert.strictEqual(created.length, 2);
+
+            resolvers.invalidate();
+            resolvers.resolvePackageDir('example.com/a/x', path.join(rootA, 'main.go'));
+            assert.strictEqual(created.length, 3);
+        });
+    });
+});
@@ -0,0 +1,116 @@
+import * as assert from 'assert';
+import * as path from 'path';
+import { PackageScanner, SourceReader } from '../packageScanner';
+
+// In-memory file system keyed by absolute path
+class FakeReader implements SourceReader {
+    files = new Map<string, string>();
+
+    readDir(dir: string): string[] {
+        return [...this.files.keys()]
+            .filter(file => path.dirname(file) === dir)
+            .map(file => path.basename(file));
+    }
+
+    readFile(file: string): string | undefined {
+        return this.files.get(file);
+    }
+}
+
+describe('PackageScanner', () => {
+    const dir = path.join(path.sep, 'project');
+    const mainFile = path.join(dir, 'main.go');
+    const modelsFile = path.join(dir, 'models.go');
+
+    let reader: FakeReader;
+    let scanner: PackageScanner;
+
+    beforeEach(() => {
+        reader = new FakeReader();
+        reader.files.set(mainFile, 'package main\n\nfunc main() {}\n');
+        reader.files.set(modelsFile, 'package main\n\ntype Person struct {\n\tName string\n}\n');
+        scanner = new PackageScanner(reader, { goos: 'darwin', goarch: 'arm64' });
+    });
+
+    it('should find structs declared in sibling files', () => {
+        const found = scanner.findStruct(mainFile, 'main', 'Person');
+        assert.ok(found);
+        assert.strictEqual(found.fields[0].name, 'Name');
+    });
+
+    it('should ignore files from a different package', () => {
+        reader.files.set(path.join(dir, 'other.go'), 'package other\n\ntype Thing struct {\n\tID int\n}\n');
+        assert.strictEqual(scanner.findStruct(mainFile, 'main', 'Thing'), undefined);
+    });
+
+    it('should only include test files when editing a test file', () => {
+        reader.files.set(path.join(dir, 'fixtures_test.go'), 'package main\n\ntype Fixture struct {\n\tID int\n}\n');
+        assert.strictEqual(scanner.findStruct(mainFile, 'main', 'Fixture'), undefined);
+        assert.ok(scanner.findStruct(path.join(dir, 'main_test.go'), 'main', 'Fixture'));
+    });
+
+    it('should skip files excluded by build constraints', () => {
+        reader.files.set(path.join(dir, 'options_windows.go'), 'package main\n\ntype Options struct {\n\tService string\n}\n');
+        reader.files.set(path.join(dir, 'options_other.go'), '//go:build !windows\n\npackage main\n\ntype Options struct {\n\tSignal int\n}\n');
+        reader.files.set(path.join(dir, 'limits.go'), '//go:build linux\n\npackage main\n\ntype Limits struct {\n\tFiles int\n}\n');
+
+        const options = scanner.findStruct(mainFile, 'main', 'Options');
+        assert.ok(options);
+        assert.strictEqual(options.fields[0].name, 'Signal');
+        assert.strictEqual(scanner.findStruct(mainFile, 'main', 'Limits'), undefined);
+    });
+
+    it('should find structs in another package directory', () => {
+        const configDir = path.join(dir, 'config');
+        reader.files.set(path.join(configDir, 'config.go'), 'package config\n\ntype Options struct {\n\tPort int\n}\n');
+        assert.strictEqual(scanner.packageNameInDir(configDir), 'config');
+        assert.ok(scanner.findStructInDir(configDir, 'Options'));
+        assert.strictEqual(scanner.findStructInDir(configDir, 'Person'), undefined);
+    });
+
+    it('should pick up changes after invalidation', () => {
+        assert.ok(scanner.findStruct(mainFile, 'main', 'Person'));
+
+        reader.files.set(modelsFile, 'package main\n\ntype User struct {\n\tName string\n}\n');
+        assert.ok(scanner.findStruct(mainFile, 'main', 'Person'), 'cached until invalidated');
+
+        scanner.invalidate(modelsFile);
+        assert.strictEqual(scanner.findStruct(mainFile, 'main', 'Person'), undefined);
+        assert.ok(scanner.findStruct(mainFile, 'main', 'User'));
+    });
+
+    it('should pick up newly created files after invalidation', () => {
+        assert.ok(scanner.findStruct(mainFile, 'main', 'Person'));
+
+        const configFile = path.join(dir, 'config.go');
+        reader.files.set(configFile, 'package main\n\ntype Config struct {\n\tPort int\n}\n');
+        scanner.invalidate(configFile);
+        assert.ok(scanner.findStruct(mainFile, 'main', 'Config'));
+    });
+
+    it('should stop resolving structs of deleted and renamed files', () => {
+        assert.ok(scanner.findStruct(mainFile, 'main', 'Person'));
+
+        reader.files.delete(modelsFile);
+        scanner.invalidate(modelsFile);
+        assert.strictEqual(scanner.findStruct(mainFile, 'main', 'Person'), undefined);
+
+        // A rename is a deletion followed by a creation
+        const renamed = path.join(dir, 'people.go');
+        reader.files.set(renamed, 'package main\n\ntype Person struct {\n\tEmail string\n}\n');
+        scanner.invalidate(renamed);
+        assert.strictEqual(scanner.findStruct(mainFile, 'main', 'Person')?.fields[0].name, 'Email');
+    });
+
+    it('should drop every file below a deleted directory', () => {
+        const configDir = path.join(dir, 'config');
+        const configFile = path.join(configDir, 'config.go');
+        reader.files.set(configFile, 'package config\n\ntype Options struct {\n\tPort int\n}\n');
+        assert.ok(scanner.findStructInDir(configDir, 'Options'));
+
+        reader.files.delete(configFile);
+        scanner.invalidate(configDir);
+        assert.strictEqual(scanner.findStructInDir(configDir, 'Options'), undefined);
+        assert.strictEqual(scanner.packageNameInDir(configDir), undefined);
+    });
+});
@@ -0,0 +1,124 @@
+import * as assert from 'assert';
+import { parseStructs } from '../goParser';
+import { fieldSnippetLines, formatFieldTable, formatStructDeclaration, visibleFieldRange, zeroValue } from '../structFormatter';
+
+describe('structFormatter', () => {
+    describe('formatStructDeclaration', () => {
+        it('should align names, types, tags and comments into columns', () => {
+            const [config] = parseStructs([
+                'type Config struct {',
+                '\tDatabaseURL string `json:"database_url"` // Connection string',
+                '\tPort int `json:"port"`',
+                '\tDebug bool // Enable debug mode',
+                '}'
+            ].join('\n'));
+            assert.strictEqual(formatStructDeclaration(config), [
+                'type Config struct {',
+                '\tDatabaseURL string `json:"database_url"` // Connection string',
+                '\tPort        int    `json:"port"`',
+                '\tDebug       bool                         // Enable debug mode',
+                '}'
+            ].join('\n'));
+        });
+
+        it('should write embedded fields as their type', () => {
+            const [employee] = parseStructs('type Employee struct { *Person; Salary int }');
+            assert.strictEqual(formatStructDeclaration(employee), 'type Employee struct {\n\t*Person\n\tSalary int\n}');
+        });
+
+        it('should render empty structs on one line', () => {
+            assert.strictEqual(formatStructDeclaration({ name: 'Empty', fields: [] }), 'type Empty struct{}');
+        });
+    });
+
+    describe('formatFieldTable', () => {
+        it('should pad names and types to the longest in their column', () => {
+            const [person] = parseStructs([
+                'type Person struct {',
+                '\tName string // Full name',
+                '\tAge int',
+                '\tEmailAddress *mail.Address // Primary contact',
+                '}'
+            ].join('\n'));
+            assert.deepStrictEqual(formatFieldTable(person.fields), [
+                'Name         string        // Full name',
+                'Age          int',
+                'EmailAddress *mail.Address // Primary contact'
+            ]);
+        });
+    });
+
+    describe('visibleFieldRange', () => {
+        it('should show every field when they fit', () => {
+            assert.deepStrictEqual(visibleFieldRange(10, 3, 25), { start: 0, end: 10 });
+        });
+
+        it('should show the first fields while the active one is among them', () => {
+            assert.deepStrictEqual(visibleFieldRange(40, 0, 25), { start: 0, end: 25 });
+            assert.deepStrictEqual(visibleFieldRange(40, 24, 25), { start: 0, end: 25 });
+        });
+
+        it('should shift the window to keep the active field visible', () => {
+            assert.deepStrictEqual(visibleFieldRange(40, 30, 25), { start: 6, end: 31 });
+            assert.deepStrictEqual(visibleFieldRange(40, 99, 25), { start: 15, end: 40 });
+        });
+    });
+
+    // Stands in for the analyzer's lookup of the structs known to a document
+    const isStruct = (type: string) => ['Address', 'time.Time', 'Box[int]'].includes(type);
+
+    describe('zeroValue', () => {
+        it('should pick zero values by type', () => {
+            const cases: [string, string][] = [
+                ['string', '""'],
+                ['int64', '0'],
+                ['float32', '0'],
+                ['byte', '0'],
+                ['bool', 'false'],
+                ['*Person', 'nil'],
+                ['[]string', 'nil'],
+                ['map[string]int', 'nil'],
+                ['chan Event', 'nil'],
+                ['func(error) error', 'nil'],
+                ['error', 'nil'],
+                ['Address', 'Address{}'],
+                ['time.Time', 'time.Time{}'],
+                ['Box[int]', 'Box[int]{}'],
+                ['[3]int', '[3]int{}'],
+                ['[2]Level', '[2]Level{}'],
+                ['struct{ X int }', 'struct{ X int }{}']
+            ];
+            for (const [type, expected] of cases) {
+                assert.strictEqual(zeroValue(type, isStruct), expected, type);
+            }
+        });
+
+        it('should not guess a literal for named types that are not known structs', () => {
+            assert.strictEqual(zeroValue('time.Duration', isStruct), undefined);
+            assert.strictEqual(zeroValue('Level', isStruct), undefined);
+            assert.strictEqual(zeroValue('io.Reader', isStruct), undefined);
+            // A type parameter of a generic struct
+            assert.strictEqual(zeroValue('T', isStruct), undefined);
+        });
+    });
+
+    describe('fieldSnippetLines', () => {
+        it('should number a tab stop per field and escape snippet syntax', () => {
+            const lines = fieldSnippetLines([
+                { name: 'Name', type: 'string' },
+                { name: 'Age', type: 'int' },
+                { name: 'Address', type: 'Address' }
+            ], isStruct);
+            assert.deepStrictEqual(lines, ['Name: ${1:""},', 'Age: ${2:0},', 'Address: ${3:Address{\\}},']);
+        });
+
+        it('should leave an empty tab stop for fields without a known zero value', () => {
+            const lines = fieldSnippetLines([
+                { name: 'Timeout', type: 'time.Duration' },
+                { name: 'Value', type: 'T' },
+                { name: 'Port', type: 'int' }
+            ], isStruct);
+            assert.deepStrictEqual(lines, ['Timeout: ${1},', 'Value: ${2},', 'Port: ${3:0},']);
+        });
+    });
+});
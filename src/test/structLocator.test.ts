@@ -0,0 +1,101 @@
+import * as assert from 'assert';
+import * as path from 'path';
+import { parseImports, parsePackageName, parseStructs } from '../goParser';
+import { ImportResolver } from '../importResolver';
+import { PackageScanner, SourceReader } from '../packageScanner';
+import { StructLocator } from '../structLocator';
+
+// In-memory file system keyed by absolute path
+class FakeReader implements SourceReader {
+    files = new Map<string, string>();
+
+    readDir(dir: string): string[] {
+        return [...this.files.keys()]
+            .filter(file => path.dirname(file) === dir)
+            .map(file => path.basename(file));
+    }
+
+    readFile(file: string): string | undefined {
+        return this.files.get(file);
+    }
+}
+
+describe('StructLocator', () => {
+    const root = path.join(path.sep, 'project');
+    const mainFile = path.join(root, 'main.go');
+    const modelsFile = path.join(root, 'models', 'models.go');
+
+    let reader: FakeReader;
+    let locator: StructLocator;
+
+    const write = (file: string, text: string) => reader.files.set(file, text);
+
+    // Looks up typeName as GoAnalyzer does for an open document with this text
+    const findUnqualified = (text: string, typeName: string) => locator.findUnqualified(
+        mainFile,
+        parsePackageName(text),
+        parseStructs(text),
+        parseImports(text),
+        typeName
+    );
+
+    beforeEach(() => {
+        reader = new FakeReader();
+        write(path.join(root, 'go.mod'), 'module example.com/x\n\ngo 1.21\n');
+        write(modelsFile, 'package models\n\ntype User struct {\n\tID    int\n\tEmail string\n}\n');
+
+        const resolver = new ImportResolver(reader, () => ({}));
+        const scanner = new PackageScanner(reader, { goos: 'darwin', goarch: 'arm64' });
+        locator = new StructLocator(scanner, resolver);
+    });
+
+    it('should resolve unqualified names through dot-imports', () => {
+        const text = 'package main\n\nimport . "example.com/x/models"\n\nfunc main() {\n\t_ = User{\n}\n';
+        write(mainFile, text);
+
+        const user = findUnqualified(text, 'User');
+        assert.ok(user);
+        assert.deepStrictEqual(user.fields.map(f => f.name), ['ID', 'Email']);
+        assert.strictEqual(user.file, modelsFile);
+
+        // Without the dot-import the name is unknown
+        const plain = 'package main\n\nimport "example.com/x/models"\n';
+        assert.strictEqual(findUnqualified(plain, 'User'), undefined);
+    });
+
+    it('should prefer declarations of the file and its package over dot-imports', () => {
+        const text = 'package main\n\nimport . "example.com/x/models"\n\ntype User struct {\n\tName string\n}\n';
+        write(mainFile, text);
+        assert.deepStrictEqual(findUnqualified(text, 'User')?.fields.map(f => f.name), ['Name']);
+
+        const sibling = 'package main\n\nimport . "example.com/x/models"\n';
+        write(mainFile, sibling);
+        write(path.join(root, 'user.go'), 'package main\n\ntype User struct {\n\tNickname string\n}\n');
+        assert.deepStrictEqual(findUnqualified(sibling, 'User')?.fields.map(f => f.name), ['Nickname']);
+    });
+
+    it('should not search blank or aliased imports for unqualified names', () => {
+        const text = 'package main\n\nimport (\n\t_ "example.com/x/models"\n\tm "example.com/x/models"\n)\n';
+        write(mainFile, text);
+
+        assert.strictEqual(findUnqualified(text, 'User'), undefined);
+        assert.ok(locator.findQualified(parseImports(text), 'm.User', mainFile));
+    });
+
+    it('should resolve dot-imports of files of other packages', () => {
+        write(path.join(root, 'api', 'handler.go'), 'package api\n\nimport . "example.com/x/models"\n\ntype Handler struct {\n\tUser\n}\n');
+        const user = locator.findUnqualifiedInFile(path.join(root, 'api', 'handler.go'), 'User');
+        assert.strictEqual(user?.file, modelsFile);
+    });
+
+    it('should look up field types from the file declaring their struct', () => {
+        write(path.join(root, 'models', 'server.go'), 'package models\n\ntype Server struct {\n\tCfg Config\n}\n\ntype Config struct {\n\tPort int\n}\n');
+        const text = 'package main\n\nimport "example.com/x/models"\n\ntype Config struct {\n\tName string\n}\n';
+        write(mainFile, text);
+
+        const server = locator.findQualified(parseImports(text), 'models.Server', mainFile);
+        assert.ok(server?.file);
+        assert.deepStrictEqual(locator.findUnqualifiedInFile(server.file, 'Config')?.fields.map(f => f.name), ['Port']);
+        assert.deepStrictEqual(findUnqualified(text, 'Config')?.fields.map(f => f.name), ['Name']);
+    });
+});
@@ -24,6 +24,12 @@ type Person struct {
 	Address Address // Home address
 }
 
+// Employee is a person with a job
+type Employee struct {
+	*Person
+	Salary int // Yearly salary
+}
+
 func main() {
 
 	d := Person{"Billy bob joe", }
@@ -39,6 +45,7 @@ func main() {
 	// Try: Person{
 	// Try: Address{
 	// Try: Config{
+	// Try: Employee{
 
 	p := Person{
 		Name: "John",